@@ -0,0 +1,918 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"my-ls-1/lister"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const progName = "my-ls-1"
+const progVersion = "1.0"
+
+// Whether an option takes an argument
+const (
+	noArgument = iota
+	requiredArgument
+	optionalArgument
+)
+
+// What the command line is read along with: the environment and the
+// terminal on stdout. main passes the real ones, tests their own.
+type environment struct {
+	getenv   func(key string) string
+	terminal bool      // Whether stdout is a terminal
+	width    int       // Columns of that terminal, 0 when unknown
+	stderr   io.Writer // Where ignored settings are reported
+}
+
+// Everything the command line decides
+type config struct {
+	env       environment
+	opts      lister.Options
+	paths     []string
+	help      bool
+	version   bool
+	sortSet   bool   // A sort order was chosen explicitly
+	timeSet   bool   // A timestamp was chosen with -c, -u or --time
+	formatSet bool   // A format was chosen explicitly
+	timeStyle string // --time-style or TIME_STYLE as given, checked once the format is known
+	color     string // When to color names: "always", "auto" or "never"
+	quoteSet  bool   // A quoting style was chosen explicitly
+	hideSet   bool   // -q or --show-control-chars was given
+	derefSet  bool   // Symlink following was chosen with -H, -L or --dereference-*
+	widthSet  bool   // The line width was given with -w
+}
+
+// One command line option. Either short or long may be empty.
+type option struct {
+	short   rune                                // Short form such as 'l', 0 when there is none
+	long    string                              // Long form without the leading dashes
+	arg     int                                 // noArgument, requiredArgument or optionalArgument
+	argName string                              // Name of the argument shown in --help
+	omitted string                              // Argument assumed when an optional one is left out
+	help    string                              // Description shown in --help
+	set     func(c *config, value string) error // Apply the option to the configuration
+}
+
+// All supported options, in the order --help lists them
+var options = []option{
+	{short: 'a', long: "all", help: "do not ignore entries starting with .", set: func(c *config, _ string) error {
+		c.opts.All = true
+		c.opts.AlmostAll = false
+		return nil
+	}},
+	{short: 'A', long: "almost-all", help: "do not list implied . and ..", set: func(c *config, _ string) error {
+		c.opts.AlmostAll = true
+		c.opts.All = false
+		return nil
+	}},
+	{short: 'b', long: "escape", help: "print C-style escapes for nongraphic characters", set: func(c *config, _ string) error {
+		setQuoting(c, lister.QuotingEscape)
+		return nil
+	}},
+	{long: "block-size", arg: requiredArgument, argName: "SIZE", help: "with -l, scale sizes by SIZE when printing them; e.g., '--block-size=M'", set: func(c *config, value string) error {
+		bs, err := lister.ParseBlockSize(value)
+		if err != nil {
+			return usageErrorf("invalid --block-size argument '%s'", value)
+		}
+		c.opts.BlockSize = bs
+		return nil
+	}},
+	{short: 'B', long: "ignore-backups", help: "do not list implied entries ending with ~", set: func(c *config, _ string) error {
+		c.opts.Ignore = append(c.opts.Ignore, "*~", ".*~")
+		return nil
+	}},
+	{short: 'C', help: "list entries by columns", set: func(c *config, _ string) error {
+		setFormat(c, lister.FormatColumns)
+		return nil
+	}},
+	{long: "color", arg: optionalArgument, argName: "WHEN", omitted: "always", help: "color the output WHEN; more info below", set: func(c *config, value string) error {
+		word, err := matchArgument("--color", value, colorWords)
+		if err != nil {
+			return err
+		}
+		c.color = colorWhen[word]
+		return nil
+	}},
+	{long: "columns", arg: requiredArgument, argName: "LIST", help: "with --format=csv or tsv, print the comma separated columns in LIST; see COLUMNS below", set: func(c *config, value string) error {
+		columns := []string{}
+		for _, word := range strings.Split(value, ",") {
+			column, err := matchArgument("--columns", word, lister.TableColumns)
+			if err != nil {
+				return err
+			}
+			columns = append(columns, column)
+		}
+		c.opts.Columns = columns
+		return nil
+	}},
+	{short: 'c', help: "with -lt: sort by, and show, ctime; with -l: show ctime and sort by name; otherwise: sort by ctime, newest first", set: func(c *config, _ string) error {
+		c.opts.Time = lister.TimeChange
+		c.timeSet = true
+		return nil
+	}},
+	{short: 'd', long: "directory", help: "list directories themselves, not their contents", set: func(c *config, _ string) error {
+		c.opts.Directory = true
+		return nil
+	}},
+	{short: 'D', long: "dired", help: "generate output designed for Emacs' dired mode", set: func(c *config, _ string) error {
+		c.opts.Dired = true
+		return nil
+	}},
+	{short: 'f', help: "list all entries in directory order", set: func(c *config, _ string) error {
+		c.opts.All = true
+		c.opts.AlmostAll = false
+		c.opts.Sort = lister.SortNone
+		c.sortSet = true
+		// -f turns -l off again, falling back to the default format
+		if c.opts.Format == lister.FormatLong {
+			c.formatSet = false
+		}
+		return nil
+	}},
+	{short: 'F', long: "classify", arg: optionalArgument, argName: "WHEN", omitted: "always", help: "append indicator (one of */=>@|) to entries WHEN", set: func(c *config, value string) error {
+		word, err := matchArgument("--classify", value, colorWords)
+		if err != nil {
+			return err
+		}
+		when := colorWhen[word]
+		// Like GNU ls, "never" leaves an earlier indicator style alone
+		if when == "always" || (when == "auto" && c.env.terminal) {
+			c.opts.Indicator = lister.IndicatorClassify
+		}
+		return nil
+	}},
+	{long: "file-type", help: "likewise, except do not append '*'", set: func(c *config, _ string) error {
+		c.opts.Indicator = lister.IndicatorFileType
+		return nil
+	}},
+	{long: "format", arg: requiredArgument, argName: "WORD", help: "across -x, commas -m, horizontal -x, long -l, single-column -1, verbose -l, vertical -C, csv, tsv", set: func(c *config, value string) error {
+		word, err := matchArgument("--format", value, formatWords)
+		if err != nil {
+			return err
+		}
+		setFormat(c, formats[word])
+		return nil
+	}},
+	{long: "full-time", help: "like -l --time-style=full-iso", set: func(c *config, _ string) error {
+		setFormat(c, lister.FormatLong)
+		return setTimeStyle(c, "full-iso")
+	}},
+	{short: 'g', help: "like -l, but do not list owner", set: func(c *config, _ string) error {
+		setFormat(c, lister.FormatLong)
+		c.opts.OmitOwner = true
+		return nil
+	}},
+	{long: "group-directories-first", help: "group directories before files; can be augmented with a --sort option, but any use of --sort=none (-U) disables grouping", set: func(c *config, _ string) error {
+		c.opts.GroupDirectoriesFirst = true
+		return nil
+	}},
+	{short: 'G', long: "no-group", help: "in a long listing, don't print group names", set: func(c *config, _ string) error {
+		c.opts.OmitGroup = true
+		return nil
+	}},
+	{long: "hide", arg: requiredArgument, argName: "PATTERN", help: "do not list implied entries matching shell PATTERN (overridden by -a or -A)", set: func(c *config, value string) error {
+		c.opts.Hide = append(c.opts.Hide, value)
+		return nil
+	}},
+	{short: 'h', long: "human-readable", help: "with -l, print sizes like 1K 234M 2G etc.", set: func(c *config, _ string) error {
+		c.opts.BlockSize = lister.BlockSize{Human: true}
+		return nil
+	}},
+	{long: "indicator-style", arg: requiredArgument, argName: "WORD", help: "append indicator with style WORD to entry names: none (default), slash (-p), file-type (--file-type), classify (-F)", set: func(c *config, value string) error {
+		word, err := matchArgument("--indicator-style", value, indicatorWords)
+		if err != nil {
+			return err
+		}
+		c.opts.Indicator = indicatorStyles[word]
+		return nil
+	}},
+	{short: 'H', long: "dereference-command-line", help: "follow symbolic links listed on the command line", set: func(c *config, _ string) error {
+		setDereference(c, lister.DereferenceCommandLine)
+		return nil
+	}},
+	{long: "dereference-command-line-symlink-to-dir", help: "follow each command line symbolic link that points to a directory", set: func(c *config, _ string) error {
+		setDereference(c, lister.DereferenceCommandLineDirs)
+		return nil
+	}},
+	{short: 'I', long: "ignore", arg: requiredArgument, argName: "PATTERN", help: "do not list implied entries matching shell PATTERN", set: func(c *config, value string) error {
+		c.opts.Ignore = append(c.opts.Ignore, value)
+		return nil
+	}},
+	{short: 'i', long: "inode", help: "print the index number of each file", set: func(c *config, _ string) error {
+		c.opts.Inode = true
+		return nil
+	}},
+	{long: "json", help: "print one JSON document per FILE, directories holding their entries", set: func(c *config, _ string) error {
+		setFormat(c, lister.FormatJSON)
+		return nil
+	}},
+	{short: 'l', help: "use a long listing format", set: func(c *config, _ string) error {
+		setFormat(c, lister.FormatLong)
+		return nil
+	}},
+	{short: 'L', long: "dereference", help: "when showing file information for a symbolic link, show information for the file the link references rather than for the link itself", set: func(c *config, _ string) error {
+		setDereference(c, lister.DereferenceAlways)
+		return nil
+	}},
+	{long: "max-depth", arg: requiredArgument, argName: "N", help: "with -R or --tree, list at most N levels below each directory operand; 1 lists its contents only", set: func(c *config, value string) error {
+		depth, err := strconv.Atoi(value)
+		if err != nil || depth < 1 {
+			return usageErrorf("invalid maximum depth '%s'", value)
+		}
+		c.opts.MaxDepth = depth
+		return nil
+	}},
+	{short: 'm', help: "fill width with a comma separated list of entries", set: func(c *config, _ string) error {
+		setFormat(c, lister.FormatCommas)
+		return nil
+	}},
+	{short: 'n', long: "numeric-uid-gid", help: "like -l, but list numeric user and group IDs", set: func(c *config, _ string) error {
+		setFormat(c, lister.FormatLong)
+		c.opts.NumericIDs = true
+		return nil
+	}},
+	{short: 'N', long: "literal", help: "print entry names without quoting", set: func(c *config, _ string) error {
+		setQuoting(c, lister.QuotingLiteral)
+		return nil
+	}},
+	{long: "no-header", help: "with --format=csv or tsv, do not print the header row", set: func(c *config, _ string) error {
+		c.opts.OmitHeader = true
+		return nil
+	}},
+	{long: "ndjson", help: "print one JSON object per line for every entry, as it is listed", set: func(c *config, _ string) error {
+		setFormat(c, lister.FormatNDJSON)
+		return nil
+	}},
+	{long: "one-file-system", help: "with -R, do not descend into directories on other file systems", set: func(c *config, _ string) error {
+		c.opts.OneFileSystem = true
+		return nil
+	}},
+	{short: 'o', help: "like -l, but do not list group information", set: func(c *config, _ string) error {
+		setFormat(c, lister.FormatLong)
+		c.opts.OmitGroup = true
+		return nil
+	}},
+	{short: 'p', help: "append / indicator to directories", set: func(c *config, _ string) error {
+		c.opts.Indicator = lister.IndicatorSlash
+		return nil
+	}},
+	{short: 'q', long: "hide-control-chars", help: "print ? instead of nongraphic characters", set: func(c *config, _ string) error {
+		c.opts.HideControl = true
+		c.hideSet = true
+		return nil
+	}},
+	{long: "show-control-chars", help: "show nongraphic characters as-is (the default, unless program is 'ls' and output is a terminal)", set: func(c *config, _ string) error {
+		c.opts.HideControl = false
+		c.hideSet = true
+		return nil
+	}},
+	{short: 'Q', long: "quote-name", help: "enclose entry names in double quotes", set: func(c *config, _ string) error {
+		setQuoting(c, lister.QuotingC)
+		return nil
+	}},
+	{long: "quoting-style", arg: requiredArgument, argName: "WORD", help: "use quoting style WORD for entry names: literal, locale, shell, shell-always, shell-escape, shell-escape-always, c, escape (overrides QUOTING_STYLE environment variable)", set: func(c *config, value string) error {
+		word, err := matchArgument("--quoting-style", value, quotingWords)
+		if err != nil {
+			return err
+		}
+		setQuoting(c, quotingStyles[word])
+		return nil
+	}},
+	{short: 'r', long: "reverse", help: "reverse order while sorting", set: func(c *config, _ string) error {
+		c.opts.Reverse = true
+		return nil
+	}},
+	{short: 'R', long: "recursive", help: "list subdirectories recursively", set: func(c *config, _ string) error {
+		c.opts.Recursive = true
+		return nil
+	}},
+	{long: "si", help: "likewise, but use powers of 1000 not 1024", set: func(c *config, _ string) error {
+		c.opts.BlockSize = lister.BlockSize{Human: true, SI: true}
+		return nil
+	}},
+	{short: 's', long: "size", help: "print the allocated size of each file, in blocks", set: func(c *config, _ string) error {
+		c.opts.Size = true
+		return nil
+	}},
+	{short: 'S', help: "sort by file size, largest first", set: func(c *config, _ string) error {
+		c.opts.Sort = lister.SortSize
+		c.sortSet = true
+		return nil
+	}},
+	{long: "sort", arg: requiredArgument, argName: "WORD", help: "sort by WORD instead of name: none (-U), size (-S), time (-t), version (-v), extension (-X)", set: func(c *config, value string) error {
+		word, err := matchArgument("--sort", value, sortWords)
+		if err != nil {
+			return err
+		}
+		c.opts.Sort = sortKeys[word]
+		c.sortSet = true
+		return nil
+	}},
+	{short: 't', help: "sort by time, newest first", set: func(c *config, _ string) error {
+		c.opts.Sort = lister.SortTime
+		c.sortSet = true
+		return nil
+	}},
+	{long: "time", arg: requiredArgument, argName: "WORD", help: "select which timestamp used to display or sort; access time (-u): atime, access, use; change time (-c): ctime, status; birth time: birth, creation; modification time: mtime, modification", set: func(c *config, value string) error {
+		word, err := matchArgument("--time", value, timeWords)
+		if err != nil {
+			return err
+		}
+		c.opts.Time = timeFields[word]
+		c.timeSet = true
+		return nil
+	}},
+	{long: "time-style", arg: requiredArgument, argName: "TIME_STYLE", help: "time/date format with -l; see TIME_STYLE below", set: setTimeStyle},
+	{long: "tree", arg: optionalArgument, argName: "CHARSET", omitted: "auto", help: "draw directories and their contents as trees, with CHARSET 'unicode', 'ascii' or 'auto' connectors; auto, the default, picks unicode in UTF-8 locales", set: func(c *config, value string) error {
+		word, err := matchArgument("--tree", value, treeWords)
+		if err != nil {
+			return err
+		}
+		// Box drawing characters need a UTF-8 locale
+		if word == "auto" {
+			word = "ascii"
+			if localeIsUTF8(c.env.getenv) {
+				word = "unicode"
+			}
+		}
+		c.opts.Tree = treeStyles[word]
+		return nil
+	}},
+	{short: 'u', help: "with -lt: sort by, and show, access time; with -l: show access time and sort by name; otherwise: sort by access time, newest first", set: func(c *config, _ string) error {
+		c.opts.Time = lister.TimeAccess
+		c.timeSet = true
+		return nil
+	}},
+	{short: 'U', help: "do not sort; list entries in directory order", set: func(c *config, _ string) error {
+		c.opts.Sort = lister.SortNone
+		c.sortSet = true
+		return nil
+	}},
+	{short: 'v', help: "natural sort of (version) numbers within text", set: func(c *config, _ string) error {
+		c.opts.Sort = lister.SortVersion
+		c.sortSet = true
+		return nil
+	}},
+	{short: 'w', long: "width", arg: requiredArgument, argName: "COLS", help: "set output width to COLS.  0 means no limit", set: func(c *config, value string) error {
+		width, err := strconv.Atoi(value)
+		if err != nil || width < 0 {
+			return usageErrorf("invalid line width: '%s'", value)
+		}
+		c.opts.Width = width
+		c.widthSet = true
+		return nil
+	}},
+	{short: 'x', help: "list entries by lines instead of by columns", set: func(c *config, _ string) error {
+		setFormat(c, lister.FormatAcross)
+		return nil
+	}},
+	{short: 'X', help: "sort alphabetically by entry extension", set: func(c *config, _ string) error {
+		c.opts.Sort = lister.SortExtension
+		c.sortSet = true
+		return nil
+	}},
+	{short: '1', help: "list one file per line", set: func(c *config, _ string) error {
+		// Like GNU ls, -1 does not turn -l off
+		if c.opts.Format != lister.FormatLong || !c.formatSet {
+			setFormat(c, lister.FormatOneLine)
+		}
+		return nil
+	}},
+	{long: "help", help: "display this help and exit", set: func(c *config, _ string) error {
+		c.help = true
+		return nil
+	}},
+	{long: "version", help: "output version information and exit", set: func(c *config, _ string) error {
+		c.version = true
+		return nil
+	}},
+}
+
+// Arguments of --format, in the order GNU ls lists them
+var formatWords = []string{"verbose", "long", "commas", "horizontal", "across", "vertical", "single-column", "csv", "tsv"}
+
+// Format selected by each argument of --format
+var formats = map[string]lister.Format{
+	"verbose":       lister.FormatLong,
+	"long":          lister.FormatLong,
+	"commas":        lister.FormatCommas,
+	"horizontal":    lister.FormatAcross,
+	"across":        lister.FormatAcross,
+	"vertical":      lister.FormatColumns,
+	"single-column": lister.FormatOneLine,
+	"csv":           lister.FormatCSV,
+	"tsv":           lister.FormatTSV,
+}
+
+// Arguments of --color, in the order GNU ls lists them
+var colorWords = []string{"always", "yes", "force", "never", "no", "none", "auto", "tty", "if-tty"}
+
+// Canonical meaning of each argument of --color
+var colorWhen = map[string]string{
+	"always": "always", "yes": "always", "force": "always",
+	"never": "never", "no": "never", "none": "never",
+	"auto": "auto", "tty": "auto", "if-tty": "auto",
+}
+
+// Arguments of --indicator-style, in the order GNU ls lists them
+var indicatorWords = []string{"none", "slash", "file-type", "classify"}
+
+// Indicator style selected by each argument of --indicator-style
+var indicatorStyles = map[string]lister.IndicatorStyle{
+	"none":      lister.IndicatorNone,
+	"slash":     lister.IndicatorSlash,
+	"file-type": lister.IndicatorFileType,
+	"classify":  lister.IndicatorClassify,
+}
+
+// Arguments of --quoting-style, in the order GNU ls lists them
+var quotingWords = []string{"literal", "shell", "shell-always", "shell-escape", "shell-escape-always", "c", "escape", "locale"}
+
+// Quoting style selected by each argument of --quoting-style
+var quotingStyles = map[string]lister.QuotingStyle{
+	"literal":             lister.QuotingLiteral,
+	"shell":               lister.QuotingShell,
+	"shell-always":        lister.QuotingShellAlways,
+	"shell-escape":        lister.QuotingShellEscape,
+	"shell-escape-always": lister.QuotingShellEscapeAlways,
+	"c":                   lister.QuotingC,
+	"escape":              lister.QuotingEscape,
+	"locale":              lister.QuotingLocale,
+}
+
+// Select the quoting style of names
+func setQuoting(c *config, style lister.QuotingStyle) {
+	c.opts.Quoting = style
+	c.quoteSet = true
+}
+
+// Select which symbolic links are followed
+func setDereference(c *config, deref lister.Dereference) {
+	c.opts.Dereference = deref
+	c.derefSet = true
+}
+
+// Arguments of --tree
+var treeWords = []string{"unicode", "ascii", "auto"}
+
+// Connectors selected by each argument of --tree
+var treeStyles = map[string]lister.TreeStyle{
+	"unicode": lister.TreeUnicode,
+	"ascii":   lister.TreeASCII,
+}
+
+// Report whether the locale encodes characters in UTF-8, going by the
+// environment variables that decide it
+func localeIsUTF8(getenv func(string) string) bool {
+	for _, name := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if value := getenv(name); value != "" {
+			value = strings.ToLower(value)
+			return strings.Contains(value, "utf-8") || strings.Contains(value, "utf8")
+		}
+	}
+	return false
+}
+
+// Report whether timestamps are formatted in the C or POSIX locale,
+// going by the environment variables that decide it
+func localeIsPOSIX(getenv func(string) string) bool {
+	for _, name := range []string{"LC_ALL", "LC_TIME", "LANG"} {
+		if value := getenv(name); value != "" {
+			return value == "C" || value == "POSIX"
+		}
+	}
+	return true
+}
+
+// Select the output format
+func setFormat(c *config, format lister.Format) {
+	c.opts.Format = format
+	c.formatSet = true
+}
+
+// Arguments of --sort, in the order GNU ls lists them
+var sortWords = []string{"none", "time", "size", "extension", "version", "name"}
+
+// Sort key selected by each argument of --sort
+var sortKeys = map[string]lister.SortKey{
+	"none":      lister.SortNone,
+	"time":      lister.SortTime,
+	"size":      lister.SortSize,
+	"extension": lister.SortExtension,
+	"version":   lister.SortVersion,
+	"name":      lister.SortName,
+}
+
+// Arguments of --time, in the order GNU ls lists them
+var timeWords = []string{"atime", "access", "use", "ctime", "status", "birth", "creation", "mtime", "modification"}
+
+// Timestamp selected by each argument of --time
+var timeFields = map[string]lister.TimeField{
+	"atime":        lister.TimeAccess,
+	"access":       lister.TimeAccess,
+	"use":          lister.TimeAccess,
+	"ctime":        lister.TimeChange,
+	"status":       lister.TimeChange,
+	"birth":        lister.TimeBirth,
+	"creation":     lister.TimeBirth,
+	"mtime":        lister.TimeModify,
+	"modification": lister.TimeModify,
+}
+
+// Record a --time-style argument. Like GNU ls, it is only parsed when
+// the listing prints timestamps with it, by applyTimeStyle.
+func setTimeStyle(c *config, value string) error {
+	c.timeStyle = value
+	return nil
+}
+
+// Apply the recorded time style, reporting invalid ones GNU style
+func applyTimeStyle(c *config) error {
+	style, err := lister.ParseTimeStyle(c.timeStyle, localeIsPOSIX(c.env.getenv))
+	if err != nil {
+		// The error names the style without its posix- prefixes
+		spec := c.timeStyle
+		for strings.HasPrefix(spec, "posix-") {
+			spec = spec[len("posix-"):]
+		}
+		msg := fmt.Sprintf("invalid argument '%s' for 'time style'\nValid arguments are:", spec)
+		for _, name := range lister.TimeStyleNames {
+			msg += "\n  - [posix-]" + name
+		}
+		msg += "\n  - +FORMAT (e.g., +%H:%M) for a 'date'-style format"
+		return &usageError{msg: msg}
+	}
+	c.opts.TimeStyle = style
+	return nil
+}
+
+// Report whether opts print timestamps with the time style: the long
+// format does, and so do tables with a time column
+func usesTimeStyle(opts lister.Options) bool {
+	switch opts.Format {
+	case lister.FormatLong:
+		return true
+	case lister.FormatCSV, lister.FormatTSV:
+		if len(opts.Columns) == 0 {
+			return true
+		}
+		for _, column := range opts.Columns {
+			switch column {
+			case "time", "atime", "mtime", "ctime", "btime":
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// A problem with the command line, reported GNU style with exit status 2
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string {
+	return e.msg
+}
+
+// Build a usage error from a format string
+func usageErrorf(format string, a ...interface{}) error {
+	return &usageError{msg: fmt.Sprintf(format, a...)}
+}
+
+// Parse the command line arguments (without the program name) in env.
+// Options and operands may be mixed freely, unless POSIXLY_CORRECT is
+// set, and everything after "--" is an operand.
+func parseArgs(args []string, env environment) (*config, error) {
+	c := &config{env: env}
+	// The environment gives the default block size, options override it
+	for _, name := range []string{"LS_BLOCK_SIZE", "BLOCK_SIZE"} {
+		if spec := env.getenv(name); spec != "" {
+			if bs, err := lister.ParseBlockSize(spec); err == nil {
+				c.opts.BlockSize = bs
+			}
+			break
+		}
+	}
+	// TIME_STYLE is the default time style, checked along with --time-style
+	c.timeStyle = env.getenv("TIME_STYLE")
+	permute := env.getenv("POSIXLY_CORRECT") == ""
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" || (!permute && len(c.paths) > 0) {
+			// Everything after the terminator, or after the first operand
+			// when permuting is off, is a file name
+			if arg == "--" {
+				i++
+			}
+			c.paths = append(c.paths, args[i:]...)
+			break
+		}
+		switch {
+		case strings.HasPrefix(arg, "--"):
+			consumed, err := parseLong(c, arg[2:], args[i+1:])
+			if err != nil {
+				return nil, err
+			}
+			i += consumed
+		case len(arg) > 1 && arg[0] == '-':
+			consumed, err := parseShort(c, arg[1:], args[i+1:])
+			if err != nil {
+				return nil, err
+			}
+			i += consumed
+		default:
+			// A lone "-" or anything without a leading dash is an operand
+			c.paths = append(c.paths, arg)
+		}
+	}
+	// Like GNU ls, -c and -u sort by their timestamp unless the listing is
+	// long or another order was asked for
+	if c.timeSet && !c.sortSet && c.opts.Format != lister.FormatLong {
+		c.opts.Sort = lister.SortTime
+	}
+	// Without a format option, columns go to terminals and single names to pipes
+	if !c.formatSet {
+		c.opts.Format = lister.FormatOneLine
+		if env.terminal {
+			c.opts.Format = lister.FormatColumns
+		}
+	}
+	// An invalid time style is only an error when timestamps are printed
+	// with it
+	if c.timeStyle != "" && usesTimeStyle(c.opts) {
+		if err := applyTimeStyle(c); err != nil {
+			return nil, err
+		}
+	}
+	// Like GNU ls, operands linking to directories are followed unless the
+	// listing shows details of the links themselves, as the long and JSON
+	// formats do
+	detailed := c.opts.Format == lister.FormatLong || c.opts.Format == lister.FormatJSON || c.opts.Format == lister.FormatNDJSON
+	if !c.derefSet && !c.opts.Directory && !detailed && c.opts.Indicator != lister.IndicatorClassify {
+		c.opts.Dereference = lister.DereferenceCommandLineDirs
+	}
+	// Without a quoting option, QUOTING_STYLE decides; failing that,
+	// terminals get names they cannot be tricked by and pipes get them as
+	// they are
+	if !c.quoteSet {
+		if env.terminal {
+			c.opts.Quoting = lister.QuotingShellEscape
+		}
+		if spec := env.getenv("QUOTING_STYLE"); spec != "" {
+			if style, ok := quotingStyles[spec]; ok {
+				c.opts.Quoting = style
+			} else {
+				fmt.Fprintf(env.stderr, "%s: ignoring invalid value of environment variable QUOTING_STYLE: '%s'\n", progName, spec)
+			}
+		}
+	}
+	if !c.hideSet {
+		c.opts.HideControl = env.terminal
+	}
+	// Colors are used when asked for, or automatically on a terminal unless
+	// NO_COLOR is set
+	if c.color == "always" || (c.color == "auto" && env.terminal && env.getenv("NO_COLOR") == "") {
+		colors, err := lister.ParseLSColors(env.getenv("LS_COLORS"))
+		if err != nil {
+			fmt.Fprintln(env.stderr, progName+": "+err.Error())
+		}
+		c.opts.Colors = colors
+	}
+	// The line width comes from -w, then $COLUMNS, then the terminal
+	if !c.widthSet {
+		c.opts.Width = 80
+		spec := env.getenv("COLUMNS")
+		if width, ok := parseColumns(spec); ok {
+			c.opts.Width = width
+		} else {
+			if spec != "" {
+				fmt.Fprintf(env.stderr, "%s: ignoring invalid width in environment variable COLUMNS: '%s'\n", progName, spec)
+			}
+			if env.width > 0 {
+				c.opts.Width = env.width
+			}
+		}
+	}
+	return c, nil
+}
+
+// Parse the value of COLUMNS the way strtoumax reads it: leading blanks
+// are skipped, and a width too large to hold means no limit in practice
+func parseColumns(spec string) (int, bool) {
+	spec = strings.TrimLeft(spec, " \t\n\v\f\r")
+	if spec == "" || spec[0] == '-' {
+		return 0, false
+	}
+	width, err := strconv.ParseUint(strings.TrimPrefix(spec, "+"), 10, 64)
+	if err != nil && !errors.Is(err, strconv.ErrRange) {
+		return 0, false
+	}
+	if width > math.MaxInt32 {
+		width = math.MaxInt32
+	}
+	return int(width), true
+}
+
+// Parse a long option such as "sort=time" or "all". rest holds the
+// arguments that follow it; the number of those consumed is returned.
+func parseLong(c *config, arg string, rest []string) (int, error) {
+	name, value, hasValue := strings.Cut(arg, "=")
+	opt, err := lookupLong(name)
+	if err != nil {
+		return 0, err
+	}
+	consumed := 0
+	switch opt.arg {
+	case noArgument:
+		if hasValue {
+			return 0, usageErrorf("option '--%s' doesn't allow an argument", opt.long)
+		}
+	case requiredArgument:
+		if !hasValue {
+			if len(rest) == 0 {
+				return 0, usageErrorf("option '--%s' requires an argument", opt.long)
+			}
+			value = rest[0]
+			consumed = 1
+		}
+	case optionalArgument:
+		// Only a missing "=" leaves the argument out; "--color=" passes ""
+		if !hasValue {
+			value = opt.omitted
+		}
+	}
+	return consumed, opt.set(c, value)
+}
+
+// Find a long option by its full name or by an unambiguous prefix of it
+func lookupLong(name string) (*option, error) {
+	matches := []*option{}
+	for i := range options {
+		opt := &options[i]
+		if opt.long == "" {
+			continue
+		}
+		if opt.long == name {
+			return opt, nil
+		}
+		if strings.HasPrefix(opt.long, name) {
+			matches = append(matches, opt)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, usageErrorf("unrecognized option '--%s'", name)
+	case 1:
+		return matches[0], nil
+	}
+	possibilities := ""
+	for _, opt := range matches {
+		possibilities += " '--" + opt.long + "'"
+	}
+	return nil, usageErrorf("option '--%s' is ambiguous; possibilities:%s", name, possibilities)
+}
+
+// Parse a cluster of short options such as "la". An option that takes an
+// argument uses the rest of the cluster, or else the next argument.
+func parseShort(c *config, cluster string, rest []string) (int, error) {
+	for i, symb := range cluster {
+		opt := lookupShort(symb)
+		if opt == nil {
+			return 0, usageErrorf("invalid option -- '%c'", symb)
+		}
+		if opt.arg == noArgument {
+			if err := opt.set(c, ""); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		// As in GNU ls, optional arguments can only be given to long forms
+		if opt.arg == optionalArgument {
+			if err := opt.set(c, opt.omitted); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		// The remainder of the cluster is the argument
+		value := cluster[i+len(string(symb)):]
+		if value != "" {
+			return 0, opt.set(c, value)
+		}
+		if len(rest) == 0 {
+			return 0, usageErrorf("option requires an argument -- '%c'", symb)
+		}
+		return 1, opt.set(c, rest[0])
+	}
+	return 0, nil
+}
+
+// Find an option by its short form
+func lookupShort(symb rune) *option {
+	for i := range options {
+		if options[i].short == symb {
+			return &options[i]
+		}
+	}
+	return nil
+}
+
+// Match value against the valid arguments of an option, accepting any
+// unambiguous prefix, and return the full argument
+func matchArgument(optName, value string, valid []string) (string, error) {
+	matches := []string{}
+	for _, thisValid := range valid {
+		if thisValid == value {
+			return thisValid, nil
+		}
+		if value != "" && strings.HasPrefix(thisValid, value) {
+			matches = append(matches, thisValid)
+		}
+	}
+	if len(matches) == 1 {
+		return matches[0], nil
+	}
+	problem := "invalid"
+	if len(matches) > 1 {
+		problem = "ambiguous"
+	}
+	msg := fmt.Sprintf("%s argument '%s' for '%s'\nValid arguments are:", problem, value, optName)
+	for _, thisValid := range valid {
+		msg += "\n  - '" + thisValid + "'"
+	}
+	return "", &usageError{msg: msg}
+}
+
+// Report a usage error and exit with status 2
+func failUsage(err error) {
+	fmt.Fprintln(os.Stderr, progName+": "+err.Error())
+	fmt.Fprintf(os.Stderr, "Try '%s --help' for more information.\n", progName)
+	os.Exit(2)
+}
+
+// Write the --help text, generated from the option table
+func printHelp(w io.Writer) {
+	fmt.Fprintf(w, "Usage: %s [OPTION]... [FILE]...\n", progName)
+	fmt.Fprintln(w, "List information about the FILEs (the current directory by default).")
+	fmt.Fprintln(w, "Sort entries alphabetically if none of -tSUvX nor --sort is specified.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Mandatory arguments to long options are mandatory for short options too.")
+	for _, opt := range options {
+		flags := "    "
+		if opt.short != 0 {
+			flags = "-" + string(opt.short)
+			if opt.long != "" {
+				flags += ", "
+			}
+		}
+		if opt.long != "" {
+			flags += "--" + opt.long
+			switch opt.arg {
+			case requiredArgument:
+				flags += "=" + opt.argName
+			case optionalArgument:
+				flags += "[=" + opt.argName + "]"
+			}
+		}
+		fmt.Fprintf(w, "  %-28s %s\n", flags, opt.help)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "The SIZE argument is an integer and optional unit (example: 10K is 10*1024).")
+	fmt.Fprintln(w, "Units are K,M,G,T,P,E,Z,Y (powers of 1024) or KB,MB,... (powers of 1000).")
+	fmt.Fprintln(w, "Binary prefixes can be used, too: KiB=K, MiB=M, and so on.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "The TIME_STYLE argument can be full-iso, long-iso, iso, locale, or +FORMAT.")
+	fmt.Fprintln(w, "FORMAT is interpreted like in date(1).  If FORMAT is FORMAT1<newline>FORMAT2,")
+	fmt.Fprintln(w, "then FORMAT1 applies to non-recent files and FORMAT2 to recent files.")
+	fmt.Fprintln(w, "TIME_STYLE prefixed with 'posix-' takes effect only outside the POSIX locale.")
+	fmt.Fprintln(w, "Also the TIME_STYLE environment variable sets the default style to use.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "COLUMNS of --format=csv and tsv are path, name, type, inode, blocks, mode,")
+	fmt.Fprintln(w, "links, user, group, uid, gid, size, time, atime, mtime, ctime, btime, target")
+	fmt.Fprintln(w, "and xattr.  By default they are those of -l, with the path in place of the name.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "The WHEN argument defaults to 'always' and can also be 'auto' or 'never'.")
+	fmt.Fprintln(w, "With --classify=auto, indicators are added only when standard output is")
+	fmt.Fprintln(w, "connected to a terminal.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Using color to distinguish file types is disabled both by default and")
+	fmt.Fprintln(w, "with --color=never.  With --color=auto, ls emits color codes only when")
+	fmt.Fprintln(w, "standard output is connected to a terminal and NO_COLOR is not set.")
+	fmt.Fprintln(w, "The LS_COLORS environment variable can change the settings.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Exit status:")
+	fmt.Fprintln(w, " 0  if OK,")
+	fmt.Fprintln(w, " 1  if minor problems (e.g., cannot access subdirectory),")
+	fmt.Fprintln(w, " 2  if serious trouble (e.g., invalid command-line option).")
+}
+
+// Write the --version text
+func printVersion(w io.Writer) {
+	fmt.Fprintf(w, "%s %s\n", progName, progVersion)
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"my-ls-1/lister"
+)
+
+// An environment holding only vars, with stdout going to a pipe
+func testEnvironment(vars map[string]string) (environment, *bytes.Buffer) {
+	stderr := &bytes.Buffer{}
+	getenv := func(key string) string {
+		return vars[key]
+	}
+	return environment{getenv: getenv, stderr: stderr}, stderr
+}
+
+// Parse args in an empty environment
+func parse(args ...string) (*config, error) {
+	env, _ := testEnvironment(nil)
+	return parseArgs(args, env)
+}
+
+// Report whether err is a usage error with message msg
+func isUsageError(err error, msg string) bool {
+	var usageErr *usageError
+	return errors.As(err, &usageErr) && usageErr.msg == msg
+}
+
+func TestLongOptionPrefixes(t *testing.T) {
+	tests := []struct {
+		args    []string
+		check   func(c *config) bool
+		wantErr string
+	}{
+		{args: []string{"--reverse"}, check: func(c *config) bool { return c.opts.Reverse }},
+		{args: []string{"--rev"}, check: func(c *config) bool { return c.opts.Reverse }},
+		{args: []string{"--almost"}, check: func(c *config) bool { return c.opts.AlmostAll }},
+		// A full name wins over the longer names it is a prefix of
+		{args: []string{"--all"}, check: func(c *config) bool { return c.opts.All }},
+		{args: []string{"--si"}, check: func(c *config) bool { return c.opts.BlockSize.SI }},
+		{args: []string{"--al"}, wantErr: "option '--al' is ambiguous; possibilities: '--all' '--almost-all'"},
+		{args: []string{"--bogus"}, wantErr: "unrecognized option '--bogus'"},
+		{args: []string{"--col=auto"}, wantErr: "option '--col' is ambiguous; possibilities: '--color' '--columns'"},
+		{args: []string{"--rev=x"}, wantErr: "option '--reverse' doesn't allow an argument"},
+		{args: []string{"--sort"}, wantErr: "option '--sort' requires an argument"},
+		// Arguments can be abbreviated too
+		{args: []string{"--sort=ext"}, check: func(c *config) bool { return c.opts.Sort == lister.SortExtension }},
+		{args: []string{"--sort", "v"}, check: func(c *config) bool { return c.opts.Sort == lister.SortVersion }},
+		{args: []string{"--time=c"}, wantErr: "ambiguous argument 'c' for '--time'\nValid arguments are:\n" +
+			"  - 'atime'\n  - 'access'\n  - 'use'\n  - 'ctime'\n  - 'status'\n  - 'birth'\n  - 'creation'\n  - 'mtime'\n  - 'modification'"},
+		{args: []string{"-x", "-Z"}, wantErr: "invalid option -- 'Z'"},
+	}
+	for _, test := range tests {
+		c, err := parse(test.args...)
+		if test.wantErr != "" {
+			if !isUsageError(err, test.wantErr) {
+				t.Errorf("%q: got error %v, want %q", test.args, err, test.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", test.args, err)
+			continue
+		}
+		if !test.check(c) {
+			t.Errorf("%q: option not applied", test.args)
+		}
+	}
+}
+
+func TestOperands(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		posixly   bool
+		wantPaths []string
+		wantAll   bool
+	}{
+		{"options anywhere", []string{"a", "-a", "b"}, false, []string{"a", "b"}, true},
+		{"terminator", []string{"--", "-a", "--"}, false, []string{"-a", "--"}, false},
+		{"terminator after options", []string{"-a", "--", "-l"}, false, []string{"-l"}, true},
+		{"lone dash", []string{"-", "-a"}, false, []string{"-"}, true},
+		{"argument of an option", []string{"-I", "-a"}, false, nil, false},
+		// POSIXLY_CORRECT stops options at the first operand
+		{"posixly correct", []string{"a", "-a", "--", "b"}, true, []string{"a", "-a", "--", "b"}, false},
+		{"posixly correct options first", []string{"-a", "a", "-l"}, true, []string{"a", "-l"}, true},
+		{"posixly correct terminator", []string{"--", "-a"}, true, []string{"-a"}, false},
+	}
+	for _, test := range tests {
+		vars := map[string]string{}
+		if test.posixly {
+			vars["POSIXLY_CORRECT"] = "1"
+		}
+		env, _ := testEnvironment(vars)
+		c, err := parseArgs(test.args, env)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if strings.Join(c.paths, " ") != strings.Join(test.wantPaths, " ") || len(c.paths) != len(test.wantPaths) {
+			t.Errorf("%s: got operands %q, want %q", test.name, c.paths, test.wantPaths)
+		}
+		if c.opts.All != test.wantAll {
+			t.Errorf("%s: got All %v, want %v", test.name, c.opts.All, test.wantAll)
+		}
+	}
+}
+
+func TestOptionArguments(t *testing.T) {
+	tests := []struct {
+		args      []string
+		wantWidth int
+		wantColor string
+		wantPaths []string
+		wantErr   string
+	}{
+		{args: []string{"-w80"}, wantWidth: 80},
+		{args: []string{"-w", "80"}, wantWidth: 80},
+		{args: []string{"-lw", "80", "a"}, wantWidth: 80, wantPaths: []string{"a"}},
+		{args: []string{"--width=0"}, wantWidth: 0},
+		{args: []string{"--width", "120"}, wantWidth: 120},
+		{args: []string{"-w"}, wantErr: "option requires an argument -- 'w'"},
+		{args: []string{"-w", "-1"}, wantErr: "invalid line width: '-1'"},
+		{args: []string{"-wx"}, wantErr: "invalid line width: 'x'"},
+		// An optional argument needs "=", or it is left out
+		{args: []string{"--color"}, wantWidth: 80, wantColor: "always"},
+		{args: []string{"--color", "never"}, wantWidth: 80, wantColor: "always", wantPaths: []string{"never"}},
+		{args: []string{"--color=never"}, wantWidth: 80, wantColor: "never"},
+		{args: []string{"--colo=if"}, wantWidth: 80, wantColor: "auto"},
+		{args: []string{"--color="}, wantErr: "invalid argument '' for '--color'\nValid arguments are:\n" +
+			"  - 'always'\n  - 'yes'\n  - 'force'\n  - 'never'\n  - 'no'\n  - 'none'\n  - 'auto'\n  - 'tty'\n  - 'if-tty'"},
+	}
+	for _, test := range tests {
+		c, err := parse(test.args...)
+		if test.wantErr != "" {
+			if !isUsageError(err, test.wantErr) {
+				t.Errorf("%q: got error %v, want %q", test.args, err, test.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", test.args, err)
+			continue
+		}
+		if c.opts.Width != test.wantWidth {
+			t.Errorf("%q: got width %d, want %d", test.args, c.opts.Width, test.wantWidth)
+		}
+		if c.color != test.wantColor {
+			t.Errorf("%q: got color %q, want %q", test.args, c.color, test.wantColor)
+		}
+		if strings.Join(c.paths, " ") != strings.Join(test.wantPaths, " ") {
+			t.Errorf("%q: got operands %q, want %q", test.args, c.paths, test.wantPaths)
+		}
+	}
+}
+
+func TestFormatAndSort(t *testing.T) {
+	tests := []struct {
+		args       []string
+		terminal   bool
+		wantFormat lister.Format
+		wantSort   lister.SortKey
+		wantTime   lister.TimeField
+	}{
+		// Without a format option, the output decides
+		{nil, false, lister.FormatOneLine, lister.SortName, lister.TimeModify},
+		{nil, true, lister.FormatColumns, lister.SortName, lister.TimeModify},
+		// -1 does not undo -l, but the last of other formats wins
+		{[]string{"-l", "-1"}, false, lister.FormatLong, lister.SortName, lister.TimeModify},
+		{[]string{"-1", "-l"}, false, lister.FormatLong, lister.SortName, lister.TimeModify},
+		{[]string{"-1"}, true, lister.FormatOneLine, lister.SortName, lister.TimeModify},
+		{[]string{"-l", "-C"}, false, lister.FormatColumns, lister.SortName, lister.TimeModify},
+		{[]string{"-C", "-1"}, false, lister.FormatOneLine, lister.SortName, lister.TimeModify},
+		// -c and -u sort by their timestamp, except in long listings
+		{[]string{"-c"}, false, lister.FormatOneLine, lister.SortTime, lister.TimeChange},
+		{[]string{"-u"}, true, lister.FormatColumns, lister.SortTime, lister.TimeAccess},
+		{[]string{"--time=birth"}, false, lister.FormatOneLine, lister.SortTime, lister.TimeBirth},
+		{[]string{"-lc"}, false, lister.FormatLong, lister.SortName, lister.TimeChange},
+		{[]string{"-ltu"}, false, lister.FormatLong, lister.SortTime, lister.TimeAccess},
+		{[]string{"-l", "-1", "-u"}, false, lister.FormatLong, lister.SortName, lister.TimeAccess},
+		// Another order given anywhere wins
+		{[]string{"-Sc"}, false, lister.FormatOneLine, lister.SortSize, lister.TimeChange},
+		{[]string{"-c", "-U"}, false, lister.FormatOneLine, lister.SortNone, lister.TimeChange},
+		{[]string{"-u", "--sort=name"}, false, lister.FormatOneLine, lister.SortName, lister.TimeAccess},
+	}
+	for _, test := range tests {
+		env, _ := testEnvironment(nil)
+		env.terminal = test.terminal
+		c, err := parseArgs(test.args, env)
+		if err != nil {
+			t.Errorf("%q: %v", test.args, err)
+			continue
+		}
+		if c.opts.Format != test.wantFormat || c.opts.Sort != test.wantSort || c.opts.Time != test.wantTime {
+			t.Errorf("%q: got format %v, sort %v, time %v, want %v, %v, %v", test.args,
+				c.opts.Format, c.opts.Sort, c.opts.Time, test.wantFormat, test.wantSort, test.wantTime)
+		}
+	}
+}
+
+func TestTimeStyleEnvironment(t *testing.T) {
+	tests := []struct {
+		args      []string
+		vars      map[string]string
+		wantStyle lister.TimeStyle
+		wantErr   bool
+	}{
+		// An invalid style only matters when timestamps are shown
+		{[]string{"a"}, map[string]string{"TIME_STYLE": "bogus"}, lister.TimeStyle{}, false},
+		{[]string{"--time-style=bogus", "-1"}, nil, lister.TimeStyle{}, false},
+		{[]string{"-l"}, map[string]string{"TIME_STYLE": "bogus"}, lister.TimeStyle{}, true},
+		{[]string{"-l", "-1", "--time-style=bogus"}, nil, lister.TimeStyle{}, true},
+		{[]string{"--format=csv"}, map[string]string{"TIME_STYLE": "bogus"}, lister.TimeStyle{}, true},
+		{[]string{"--format=csv", "--columns=name"}, map[string]string{"TIME_STYLE": "bogus"}, lister.TimeStyle{}, false},
+		// The option overrides the environment
+		{[]string{"-l", "--time-style=+%Y"}, map[string]string{"TIME_STYLE": "bogus"}, lister.TimeStyle{Recent: "%Y", Old: "%Y", Explicit: true}, false},
+		// posix- styles depend on the locale
+		{[]string{"-l", "--time-style=posix-+%Y"}, nil, lister.TimeStyle{Recent: "%b %e %H:%M", Old: "%b %e  %Y", Explicit: true}, false},
+		{[]string{"-l", "--time-style=posix-+%Y"}, map[string]string{"LANG": "C.UTF-8"}, lister.TimeStyle{Recent: "%Y", Old: "%Y", Explicit: true}, false},
+		{[]string{"-l", "--time-style=posix-+%Y"}, map[string]string{"LC_ALL": "POSIX", "LANG": "C.UTF-8"}, lister.TimeStyle{Recent: "%b %e %H:%M", Old: "%b %e  %Y", Explicit: true}, false},
+	}
+	for _, test := range tests {
+		env, _ := testEnvironment(test.vars)
+		c, err := parseArgs(test.args, env)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("%q %v: no error", test.args, test.vars)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q %v: %v", test.args, test.vars, err)
+			continue
+		}
+		if c.opts.TimeStyle != test.wantStyle {
+			t.Errorf("%q %v: got %+v, want %+v", test.args, test.vars, c.opts.TimeStyle, test.wantStyle)
+		}
+	}
+}
+
+func TestLineWidth(t *testing.T) {
+	tests := []struct {
+		columns       string
+		terminalWidth int
+		args          []string
+		wantWidth     int
+		wantWarning   bool
+	}{
+		{"", 0, nil, 80, false},
+		{"", 120, nil, 120, false},
+		{"100", 120, nil, 100, false},
+		{"0", 120, nil, 0, false},
+		{" 40", 0, nil, 40, false},
+		{"+30", 0, nil, 30, false},
+		{"99999999999999999999", 0, nil, math.MaxInt32, false},
+		// -w wins over COLUMNS, which is not even looked at
+		{"abc", 0, []string{"-w", "50"}, 50, false},
+		// Invalid values are reported and the terminal decides
+		{"abc", 0, nil, 80, true},
+		{"abc", 120, nil, 120, true},
+		{"-5", 0, nil, 80, true},
+		{"40x", 0, nil, 80, true},
+	}
+	for _, test := range tests {
+		env, stderr := testEnvironment(map[string]string{"COLUMNS": test.columns})
+		env.width = test.terminalWidth
+		c, err := parseArgs(test.args, env)
+		if err != nil {
+			t.Errorf("COLUMNS=%q: %v", test.columns, err)
+			continue
+		}
+		if c.opts.Width != test.wantWidth {
+			t.Errorf("COLUMNS=%q: got width %d, want %d", test.columns, c.opts.Width, test.wantWidth)
+		}
+		warning := ""
+		if test.wantWarning {
+			warning = progName + ": ignoring invalid width in environment variable COLUMNS: '" + test.columns + "'\n"
+		}
+		if stderr.String() != warning {
+			t.Errorf("COLUMNS=%q: got %q, want %q", test.columns, stderr.String(), warning)
+		}
+	}
+}
+
+func TestEnvironmentWarnings(t *testing.T) {
+	env, stderr := testEnvironment(map[string]string{"QUOTING_STYLE": "bogus", "COLUMNS": "100"})
+	c, err := parseArgs(nil, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := progName + ": ignoring invalid value of environment variable QUOTING_STYLE: 'bogus'\n"; stderr.String() != want {
+		t.Errorf("got %q, want %q", stderr.String(), want)
+	}
+	if c.opts.Width != 100 {
+		t.Errorf("got width %d, want 100", c.opts.Width)
+	}
+}
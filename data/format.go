@@ -1,7 +1,7 @@
 package data
 
 import (
-	"fmt"
+	"io"
 	"strings"
 )
 
@@ -11,20 +11,38 @@ type PrintFormat struct {
 	minWidth    []int
 	colWidth    []int
 	rows        [][]string
+	prefix      string // Written at the start of every row
+	marks       []mark // Bytes marked by AddMarkedRow, in row order
+	spans       []Span // Where the last Flush wrote the marked bytes
 }
 
-// Added row. Use \t for column break.
+// A range of bytes in the output, from Start up to but not including End
+type Span struct {
+	Start, End int
+}
+
+// Bytes start to end of column col of a row
+type mark struct {
+	row, col, start, end int
+}
+
+// Added row. Use \t for column break. Columns are measured in terminal
+// cells, so wide characters and color sequences keep them aligned. The
+// last column of the align format takes the rest of the row, tabs included.
 func (fp *PrintFormat) AddRow(str string) {
 	// split string by \t
 	rowParts := strings.Split(str, "\t")
+	if len(fp.alignFormat) > 0 {
+		rowParts = strings.SplitN(str, "\t", len(fp.alignFormat))
+	}
 
 	// Prepare length for format
 	for i, thisPart := range rowParts {
 		// check if a minimum width has been set for this column
 		if len(fp.minWidth)-1 >= i {
 			// if this column's content is shorter than the minimum width, pad it with spaces
-			if len(thisPart) < fp.minWidth[i] {
-				for j := len(thisPart); j < fp.minWidth[i]; j++ {
+			if DisplayWidth(thisPart) < fp.minWidth[i] {
+				for j := DisplayWidth(thisPart); j < fp.minWidth[i]; j++ {
 					thisPart = " " + thisPart
 				}
 			}
@@ -32,13 +50,13 @@ func (fp *PrintFormat) AddRow(str string) {
 
 		// If this column has not been added yet to col width tracker, add it
 		if len(fp.colWidth)-1 < i {
-			fp.colWidth = append(fp.colWidth, len(thisPart))
+			fp.colWidth = append(fp.colWidth, DisplayWidth(thisPart))
 			continue
 		}
 
 		// if the current part is longer than the previous content of this column, update the width
-		if len(thisPart) > fp.colWidth[i] {
-			fp.colWidth[i] = len(thisPart)
+		if DisplayWidth(thisPart) > fp.colWidth[i] {
+			fp.colWidth[i] = DisplayWidth(thisPart)
 		}
 	}
 
@@ -46,16 +64,57 @@ func (fp *PrintFormat) AddRow(str string) {
 	fp.rows = append(fp.rows, rowParts)
 }
 
-// Print everything in memory
-func (fp *PrintFormat) Flush() {
+// Add a row like AddRow, marking the bytes str[start:end], which must lie
+// within one column. Flush reports where the marked bytes land in its output.
+func (fp *PrintFormat) AddMarkedRow(str string, start, end int) {
+	fp.AddRow(str)
+	row := len(fp.rows) - 1
+	// Find the column holding the start of the marked bytes; each column but
+	// the last is followed by the tab that ended it
+	offset := 0
+	for col, thisPart := range fp.rows[row] {
+		if start < offset+len(thisPart) || col == len(fp.rows[row])-1 {
+			fp.marks = append(fp.marks, mark{row: row, col: col, start: start - offset, end: end - offset})
+			return
+		}
+		offset += len(thisPart) + 1
+	}
+}
+
+// Write prefix at the start of every row
+func (fp *PrintFormat) SetPrefix(prefix string) {
+	fp.prefix = prefix
+}
+
+// Return where the bytes marked by AddMarkedRow were written by the last
+// Flush, as offsets from the start of its output, in the order of the rows
+func (fp *PrintFormat) Spans() []Span {
+	return fp.spans
+}
+
+// Write everything in memory to w. The buffered rows are discarded even
+// when writing fails, and the first write error is returned.
+func (fp *PrintFormat) Flush(w io.Writer) error {
+	// Clear the rows and column width data from the PrintFormat struct once done
+	defer func() {
+		fp.rows = nil
+		fp.colWidth = nil
+		fp.marks = nil
+	}()
+	fp.spans = nil
+	written := 0 // Bytes written so far, locating the marked bytes
+	nextMark := 0
+
 	// Iterate through each row in the fp.rows slice
-	for _, thisRow := range fp.rows {
-		// Initialize an empty string to hold the formatted row
-		printRow := ""
+	for rowIndex, thisRow := range fp.rows {
+		// Initialize the formatted row with the prefix
+		printRow := fp.prefix
+		// Remember where every column starts within the row
+		colStart := make([]int, len(thisRow))
 		// Iterate through each column/part in thisRow
 		for i, thisRowPart := range thisRow {
 			// Calculate the number of spaces needed to pad the current column
-			addSpace := fp.colWidth[i] - len(thisRowPart)
+			addSpace := fp.colWidth[i] - DisplayWidth(thisRowPart)
 
 			// Generate the string of spaces to use for padding
 			spaceing := ""
@@ -72,7 +131,9 @@ func (fp *PrintFormat) Flush() {
 			}
 
 			// Add the current column/part to the formatted row, padding with spaces as needed
+			colStart[i] = len(printRow)
 			if alignRight {
+				colStart[i] += len(spaceing)
 				printRow += spaceing + thisRowPart
 			} else {
 				if i >= len(thisRow)-1 { // If last column, do not add space at end
@@ -87,13 +148,20 @@ func (fp *PrintFormat) Flush() {
 				printRow += " "
 			}
 		}
-		// Print the formatted row to the console, excluding the final newline character
-		fmt.Println(printRow[:len(printRow)-1])
+		// Locate the marked bytes of this row in the output
+		for ; nextMark < len(fp.marks) && fp.marks[nextMark].row == rowIndex; nextMark++ {
+			thisMark := fp.marks[nextMark]
+			start := written + colStart[thisMark.col]
+			fp.spans = append(fp.spans, Span{Start: start + thisMark.start, End: start + thisMark.end})
+		}
+		// Write the formatted row, dropping the trailing column spacing
+		printRow = printRow[:len(printRow)-fp.addSpace] + "\n"
+		if _, err := io.WriteString(w, printRow); err != nil {
+			return err
+		}
+		written += len(printRow)
 	}
-
-	// Clear the rows and column width data from the PrintFormat struct
-	fp.rows = nil
-	fp.colWidth = nil
+	return nil
 }
 
 // add space determin minimum space between, align format as slice of string with l or r (left or right)
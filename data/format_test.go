@@ -0,0 +1,206 @@
+package data
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestFlushAlignment(t *testing.T) {
+	tests := []struct {
+		name        string
+		addSpace    int
+		alignFormat []string
+		minWidth    []int
+		rows        []string
+		want        string
+	}{
+		{
+			name:        "left and right",
+			addSpace:    1,
+			alignFormat: []string{"l", "r", "l"},
+			rows:        []string{"a\t1\tx", "bbb\t22\tyy"},
+			want:        "a    1 x\nbbb 22 yy\n",
+		},
+		{
+			name:        "minimum width",
+			addSpace:    1,
+			alignFormat: []string{"r", "l"},
+			minWidth:    []int{3},
+			rows:        []string{"7\tx"},
+			want:        "  7 x\n",
+		},
+		{
+			name:        "wider gap",
+			addSpace:    2,
+			alignFormat: []string{"l", "l"},
+			rows:        []string{"a\tx", "bb\ty"},
+			want:        "a   x\nbb  y\n",
+		},
+		{
+			name:        "last column keeps its tabs",
+			addSpace:    1,
+			alignFormat: []string{"l", "l"},
+			rows:        []string{"a\tb\tc"},
+			want:        "a b\tc\n",
+		},
+		{
+			name:        "no gap",
+			alignFormat: []string{"l", "l"},
+			rows:        []string{"a\tx", "bb\ty"},
+			want:        "a x\nbby\n",
+		},
+		{
+			name:     "no align format",
+			addSpace: 1,
+			rows:     []string{"a\tb\tc", "dd\te\tf"},
+			want:     " a b c\ndd e f\n",
+		},
+		{
+			name:        "wide characters",
+			addSpace:    1,
+			alignFormat: []string{"l", "l"},
+			rows:        []string{"日本\tx", "ab\ty"},
+			want:        "日本 x\nab   y\n",
+		},
+		{
+			name:        "colored cells",
+			addSpace:    1,
+			alignFormat: []string{"r", "l"},
+			rows:        []string{"\x1b[01;34mab\x1b[0m\tx", "abc\ty"},
+			want:        " \x1b[01;34mab\x1b[0m x\nabc y\n",
+		},
+	}
+	for _, test := range tests {
+		fp := FormatPrint(test.addSpace, test.alignFormat, test.minWidth)
+		for _, row := range test.rows {
+			fp.AddRow(row)
+		}
+		var buf bytes.Buffer
+		if err := fp.Flush(&buf); err != nil {
+			t.Fatalf("%s: Flush: %v", test.name, err)
+		}
+		if got := buf.String(); got != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestFlushDiscardsRows(t *testing.T) {
+	fp := FormatPrint(1, []string{"l", "l"}, nil)
+	fp.AddRow("long name\tx")
+	fp.Flush(&bytes.Buffer{})
+	// Widths start over along with the rows
+	fp.AddRow("a\ty")
+	var buf bytes.Buffer
+	fp.Flush(&buf)
+	if got, want := buf.String(), "a y\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSetPrefix(t *testing.T) {
+	fp := FormatPrint(1, []string{"r", "l"}, nil)
+	fp.SetPrefix("  ")
+	fp.AddRow("1\ta")
+	fp.AddRow("22\tb")
+	var buf bytes.Buffer
+	fp.Flush(&buf)
+	if got, want := buf.String(), "   1 a\n  22 b\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSpans(t *testing.T) {
+	tests := []struct {
+		name   string
+		prefix string
+		rows   []string
+		marked []string // Marked text of every row, found in the row
+	}{
+		{
+			name:   "last column",
+			rows:   []string{"1\tjan\tname", "100\tfeb\tother name"},
+			marked: []string{"name", "other name"},
+		},
+		{
+			name:   "within the last column",
+			rows:   []string{"1\tjan\t'a b' -> c", "22\tfeb\t\x1b[01;34mdir\x1b[0m"},
+			marked: []string{"a b", "dir"},
+		},
+		{
+			name:   "right aligned column",
+			rows:   []string{"1\tjan\tx", "333\tfeb\ty"},
+			marked: []string{"1", "333"},
+		},
+		{
+			name:   "prefix",
+			prefix: "  ",
+			rows:   []string{"1\tjan\tname", "22\tfeb\tdir"},
+			marked: []string{"name", "dir"},
+		},
+	}
+	for _, test := range tests {
+		fp := FormatPrint(1, []string{"r", "l", "l"}, nil)
+		fp.SetPrefix(test.prefix)
+		for i, row := range test.rows {
+			start := bytes.Index([]byte(row), []byte(test.marked[i]))
+			fp.AddMarkedRow(row, start, start+len(test.marked[i]))
+		}
+		var buf bytes.Buffer
+		fp.Flush(&buf)
+		out := buf.String()
+		spans := fp.Spans()
+		if len(spans) != len(test.marked) {
+			t.Fatalf("%s: got %d spans, want %d", test.name, len(spans), len(test.marked))
+		}
+		for i, span := range spans {
+			if got := out[span.Start:span.End]; got != test.marked[i] {
+				t.Errorf("%s: span %d covers %q in %q, want %q", test.name, i, got, out, test.marked[i])
+			}
+		}
+	}
+}
+
+func TestSpansPinned(t *testing.T) {
+	fp := FormatPrint(1, []string{"r", "l"}, nil)
+	fp.SetPrefix("  ")
+	fp.AddMarkedRow("1\tab", 2, 4)
+	fp.AddRow("22\tunmarked")
+	fp.AddMarkedRow("3\tcd", 2, 4)
+	fp.Flush(&bytes.Buffer{})
+	// "   1 ab\n  22 unmarked\n   3 cd\n"
+	want := []Span{{5, 7}, {27, 29}}
+	got := fp.Spans()
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+// A writer failing after a number of writes
+type failingWriter struct {
+	left int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.left == 0 {
+		return 0, errors.New("write failed")
+	}
+	w.left--
+	return len(p), nil
+}
+
+func TestFlushError(t *testing.T) {
+	fp := FormatPrint(1, []string{"l"}, nil)
+	fp.AddRow("a")
+	fp.AddRow("b")
+	if err := fp.Flush(&failingWriter{left: 1}); err == nil {
+		t.Error("Flush did not report the failed write")
+	}
+	// The rows are gone even though writing failed
+	var buf bytes.Buffer
+	fp.Flush(&buf)
+	if buf.Len() != 0 {
+		t.Errorf("rows survived a failed Flush: %q", buf.String())
+	}
+}
@@ -0,0 +1,153 @@
+package data
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// Code points taking two columns on a terminal: the East Asian Wide and
+// Fullwidth ranges of Unicode 15, including emoji with emoji presentation
+var wideRanges = [][2]rune{
+	{0x1100, 0x115F}, {0x231A, 0x231B}, {0x2329, 0x232A}, {0x23E9, 0x23EC},
+	{0x23F0, 0x23F0}, {0x23F3, 0x23F3}, {0x25FD, 0x25FE}, {0x2614, 0x2615},
+	{0x2648, 0x2653}, {0x267F, 0x267F}, {0x2693, 0x2693}, {0x26A1, 0x26A1},
+	{0x26AA, 0x26AB}, {0x26BD, 0x26BE}, {0x26C4, 0x26C5}, {0x26CE, 0x26CE},
+	{0x26D4, 0x26D4}, {0x26EA, 0x26EA}, {0x26F2, 0x26F3}, {0x26F5, 0x26F5},
+	{0x26FA, 0x26FA}, {0x26FD, 0x26FD}, {0x2705, 0x2705}, {0x270A, 0x270B},
+	{0x2728, 0x2728}, {0x274C, 0x274C}, {0x274E, 0x274E}, {0x2753, 0x2755},
+	{0x2757, 0x2757}, {0x2795, 0x2797}, {0x27B0, 0x27B0}, {0x27BF, 0x27BF},
+	{0x2B1B, 0x2B1C}, {0x2B50, 0x2B50}, {0x2B55, 0x2B55}, {0x2E80, 0x303E},
+	{0x3041, 0x33FF}, {0x3400, 0x4DBF}, {0x4E00, 0x9FFF}, {0xA000, 0xA4CF},
+	{0xA960, 0xA97F}, {0xAC00, 0xD7A3}, {0xF900, 0xFAFF}, {0xFE10, 0xFE19},
+	{0xFE30, 0xFE6F}, {0xFF00, 0xFF60}, {0xFFE0, 0xFFE6}, {0x16FE0, 0x16FE4},
+	{0x16FF0, 0x16FF1}, {0x17000, 0x18CFF}, {0x18D00, 0x18D08}, {0x1AFF0, 0x1B2FF},
+	{0x1F004, 0x1F004}, {0x1F0CF, 0x1F0CF}, {0x1F18E, 0x1F18E}, {0x1F191, 0x1F19A},
+	{0x1F1E6, 0x1F1FF}, {0x1F200, 0x1F202}, {0x1F210, 0x1F23B}, {0x1F240, 0x1F248},
+	{0x1F250, 0x1F251}, {0x1F260, 0x1F265}, {0x1F300, 0x1F320}, {0x1F32D, 0x1F335},
+	{0x1F337, 0x1F37C}, {0x1F37E, 0x1F393}, {0x1F3A0, 0x1F3CA}, {0x1F3CF, 0x1F3D3},
+	{0x1F3E0, 0x1F3F0}, {0x1F3F4, 0x1F3F4}, {0x1F3F8, 0x1F43E}, {0x1F440, 0x1F440},
+	{0x1F442, 0x1F4FC}, {0x1F4FF, 0x1F53D}, {0x1F54B, 0x1F54E}, {0x1F550, 0x1F567},
+	{0x1F57A, 0x1F57A}, {0x1F595, 0x1F596}, {0x1F5A4, 0x1F5A4}, {0x1F5FB, 0x1F64F},
+	{0x1F680, 0x1F6C5}, {0x1F6CC, 0x1F6CC}, {0x1F6D0, 0x1F6D2}, {0x1F6D5, 0x1F6D7},
+	{0x1F6DC, 0x1F6DF}, {0x1F6EB, 0x1F6EC}, {0x1F6F4, 0x1F6FC}, {0x1F7E0, 0x1F7EB},
+	{0x1F7F0, 0x1F7F0}, {0x1F90C, 0x1F93A}, {0x1F93C, 0x1F945}, {0x1F947, 0x1F9FF},
+	{0x1FA70, 0x1FAFF}, {0x20000, 0x2FFFD}, {0x30000, 0x3FFFD},
+}
+
+// Special code points of the grapheme cluster rules
+const (
+	zeroWidthJoiner   = 0x200D
+	variationSelector = 0xFE0F // Requests emoji presentation of the previous character
+	regionalFirst     = 0x1F1E6
+	regionalLast      = 0x1F1FF
+	modifierFirst     = 0x1F3FB // Emoji skin tone modifiers
+	modifierLast      = 0x1F3FF
+)
+
+// Return the number of terminal columns s takes. ANSI escape sequences
+// take none, East Asian wide characters and emoji take two, and every
+// grapheme cluster (a character with its combining marks, an emoji ZWJ
+// sequence or a flag) is measured as a whole.
+func DisplayWidth(s string) int {
+	width := 0
+	clusterWidth := 0     // Width of the cluster being measured
+	joinNext := false     // The previous rune was a zero width joiner
+	regionalOpen := false // A flag is waiting for its second regional indicator
+	for i := 0; i < len(s); {
+		// Escape sequences are skipped as a whole
+		if s[i] == 0x1b {
+			i += escapeLength(s[i:])
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		switch {
+		case r == utf8.RuneError && size == 1:
+			// Invalid bytes are shown as a single replacement character
+			width += clusterWidth
+			clusterWidth = 1
+		case r == zeroWidthJoiner:
+			joinNext = true
+			continue
+		case r == variationSelector:
+			// Emoji presentation makes a narrow symbol wide
+			if clusterWidth == 1 {
+				clusterWidth = 2
+			}
+		case joinNext && !unicode.IsLetter(r) && !unicode.IsDigit(r), r >= modifierFirst && r <= modifierLast && clusterWidth > 0:
+			// Part of the current cluster. Joiners only make emoji sequences,
+			// so letters after one start a cluster of their own.
+		case r >= regionalFirst && r <= regionalLast && regionalOpen:
+			// Second half of a flag
+			regionalOpen = false
+		case isZeroWidth(r):
+			// Combining marks and format characters extend the cluster
+		default:
+			width += clusterWidth
+			clusterWidth = runeWidth(r)
+			regionalOpen = r >= regionalFirst && r <= regionalLast
+		}
+		joinNext = false
+	}
+	return width + clusterWidth
+}
+
+// Return the length of the escape sequence at the start of s: a CSI
+// sequence such as a color, an OSC sequence ended by BEL or ST, or a
+// two-byte escape
+func escapeLength(s string) int {
+	if len(s) < 2 {
+		return len(s)
+	}
+	switch s[1] {
+	case '[':
+		// Parameters and intermediates up to a final byte in 0x40-0x7E
+		for i := 2; i < len(s); i++ {
+			if s[i] >= 0x40 && s[i] <= 0x7e {
+				return i + 1
+			}
+		}
+		return len(s)
+	case ']':
+		for i := 2; i < len(s); i++ {
+			if s[i] == 0x07 {
+				return i + 1
+			}
+			if s[i] == 0x1b && i+1 < len(s) && s[i+1] == '\\' {
+				return i + 2
+			}
+		}
+		return len(s)
+	}
+	return 2
+}
+
+// Report whether r takes no room of its own
+func isZeroWidth(r rune) bool {
+	// Hangul vowels and final consonants join the preceding syllable
+	if (r >= 0x1160 && r <= 0x11FF) || (r >= 0xD7B0 && r <= 0xD7FF) {
+		return true
+	}
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf)
+}
+
+// Width of a single code point starting a cluster
+func runeWidth(r rune) int {
+	if r < 0x20 || (r >= 0x7f && r < 0xa0) {
+		return 0 // Control characters
+	}
+	// Binary search the wide ranges
+	lo, hi := 0, len(wideRanges)-1
+	for lo <= hi {
+		mid := (lo + hi) / 2
+		switch {
+		case r < wideRanges[mid][0]:
+			hi = mid - 1
+		case r > wideRanges[mid][1]:
+			lo = mid + 1
+		default:
+			return 2
+		}
+	}
+	return 1
+}
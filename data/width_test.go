@@ -0,0 +1,57 @@
+package data
+
+import "testing"
+
+func TestDisplayWidth(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want int
+	}{
+		{"empty", "", 0},
+		{"ascii", "hello", 5},
+		{"latin", "héllo", 5},
+		{"invalid utf-8", "a\xffb", 3},
+		{"control", "a\tb", 2},
+		// East Asian wide characters
+		{"cjk", "日本語", 6},
+		{"cjk and ascii", "a日b", 4},
+		{"hangul syllables", "한국", 4},
+		{"fullwidth", "ＡＢ", 4},
+		{"conjoining jamo", "각", 2},
+		// Combining marks stay within their character
+		{"combining acute", "é", 1},
+		{"stacked marks", "à́̂", 1},
+		{"enclosing mark", "1⃣", 1},
+		{"leading mark", "́a", 1},
+		// Emoji
+		{"emoji", "😀", 2},
+		{"emoji presentation", "❤️", 2},
+		{"text presentation", "❤", 1},
+		{"skin tone", "👍🏽", 2},
+		{"zwj family", "👨‍👩‍👧‍👦", 2},
+		{"zwj profession", "👩🏽‍💻", 2},
+		{"two families", "👨‍👩‍👧👨‍👩‍👧", 4},
+		{"joiner between letters", "a‍b", 2},
+		{"joiner between cjk", "日‍本", 4},
+		{"zwj with text symbol", "🧑‍⚕️", 2},
+		{"rainbow flag", "🏳️‍🌈", 2},
+		// Flags are pairs of regional indicators
+		{"flag", "🇫🇷", 2},
+		{"two flags", "🇫🇷🇩🇪", 4},
+		{"lone regional indicator", "🇫", 2},
+		{"three regional indicators", "🇫🇷🇩", 4},
+		// Escape sequences take no room
+		{"colored", "\x1b[01;34mdir\x1b[0m", 3},
+		{"colored cjk", "\x1b[01;32m日本\x1b[0m", 4},
+		{"reset only", "\x1b[0m", 0},
+		{"hyperlink", "\x1b]8;;file:///tmp\x1b\\name\x1b]8;;\x1b\\", 4},
+		{"hyperlink ended by bel", "\x1b]8;;file:///tmp\aname\x1b]8;;\a", 4},
+		{"truncated escape", "a\x1b[01", 1},
+	}
+	for _, test := range tests {
+		if got := DisplayWidth(test.s); got != test.want {
+			t.Errorf("%s: DisplayWidth(%q) = %d, want %d", test.name, test.s, got, test.want)
+		}
+	}
+}
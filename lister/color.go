@@ -0,0 +1,269 @@
+package lister
+
+import (
+	"errors"
+	"io/fs"
+	"strconv"
+	"strings"
+)
+
+// Colors holds the SGR sequences used to color names, as configured by
+// the LS_COLORS environment variable
+type Colors struct {
+	types      map[string]string // Two-letter indicator codes such as "di" or "ex"
+	extensions []colorSuffix     // "*.ext=..." entries, later entries win
+}
+
+// Color of names ending in a given suffix
+type colorSuffix struct {
+	suffix string
+	code   string
+}
+
+// Default colors of the GNU dircolors database for file types
+var defaultTypeColors = map[string]string{
+	"lc": "\x1b[", "rc": "m", "rs": "0", "no": "", "fi": "",
+	"di": "01;34", "ln": "01;36", "mh": "00", "pi": "40;33", "so": "01;35",
+	"do": "01;35", "bd": "40;33;01", "cd": "40;33;01", "or": "40;31;01",
+	"mi": "00", "su": "37;41", "sg": "30;43", "ca": "00", "tw": "30;42",
+	"ow": "34;42", "st": "37;44", "ex": "01;32",
+}
+
+// Default colors of the GNU dircolors database for extensions
+var defaultExtensionColors = []struct {
+	code       string
+	extensions string
+}{
+	// Archives and compressed files
+	{"01;31", ".tar .tgz .arc .arj .taz .lha .lz4 .lzh .lzma .tlz .txz .tzo .t7z .zip .z .dz .gz .lrz .lz .lzo .xz .zst .tzst .bz2 .bz .tbz .tbz2 .tz .deb .rpm .jar .war .ear .sar .rar .alz .ace .zoo .cpio .7z .rz .cab .wim .swm .dwm .esd"},
+	// Images and videos
+	{"01;35", ".avif .jpg .jpeg .mjpg .mjpeg .gif .bmp .pbm .pgm .ppm .tga .xbm .xpm .tif .tiff .png .svg .svgz .mng .pcx .mov .mpg .mpeg .m2v .mkv .webm .webp .ogm .mp4 .m4v .mp4v .vob .qt .nuv .wmv .asf .rm .rmvb .flc .avi .fli .flv .gl .dl .xcf .xwd .yuv .cgm .emf .ogv .ogx"},
+	// Audio
+	{"00;36", ".aac .au .flac .m4a .mid .midi .mka .mp3 .mpc .ogg .ra .wav .oga .opus .spx .xspf"},
+	// Backup and temporary files
+	{"00;90", "~ # .bak .crdownload .dpkg-dist .dpkg-new .dpkg-old .dpkg-tmp .old .orig .part .rej .rpmnew .rpmorig .rpmsave .swp .tmp .ucf-dist .ucf-new .ucf-old"},
+}
+
+// DefaultColors returns the colors GNU ls uses when LS_COLORS is not set
+func DefaultColors() *Colors {
+	colors := &Colors{types: map[string]string{}}
+	for code, value := range defaultTypeColors {
+		colors.types[code] = value
+	}
+	for _, group := range defaultExtensionColors {
+		for _, ext := range strings.Fields(group.extensions) {
+			colors.extensions = append(colors.extensions, colorSuffix{suffix: ext, code: group.code})
+		}
+	}
+	return colors
+}
+
+// ParseLSColors parses the value of LS_COLORS: a colon separated list of
+// "xx=CODE" entries for file types and "*SUFFIX=CODE" entries for names.
+// Entries override the GNU defaults; an empty value keeps them all.
+func ParseLSColors(spec string) (*Colors, error) {
+	colors := DefaultColors()
+	if spec == "" {
+		return colors, nil
+	}
+	// Extensions given in LS_COLORS replace the default database
+	colors.extensions = nil
+	for _, item := range strings.Split(spec, ":") {
+		if item == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, errors.New("unparsable value for LS_COLORS environment variable")
+		}
+		code, err := unescapeColor(value)
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(key, "*") {
+			colors.extensions = append(colors.extensions, colorSuffix{suffix: key[1:], code: code})
+			continue
+		}
+		if len(key) != 2 {
+			return nil, errors.New("unrecognized prefix: " + key)
+		}
+		colors.types[key] = code
+	}
+	return colors, nil
+}
+
+// Expand the backslash and caret escapes dircolors allows in values
+func unescapeColor(value string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		switch {
+		case value[i] == '\\' && i+1 < len(value):
+			i++
+			switch value[i] {
+			case 'a':
+				b.WriteByte('\a')
+			case 'b':
+				b.WriteByte('\b')
+			case 'e':
+				b.WriteByte(0x1b)
+			case 'f':
+				b.WriteByte('\f')
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case 'v':
+				b.WriteByte('\v')
+			case '_':
+				b.WriteByte(' ')
+			case '0', '1', '2', '3', '4', '5', '6', '7':
+				// Up to three octal digits
+				end := i + 1
+				for end < len(value) && end < i+3 && value[end] >= '0' && value[end] <= '7' {
+					end++
+				}
+				n, _ := strconv.ParseUint(value[i:end], 8, 8)
+				b.WriteByte(byte(n))
+				i = end - 1
+			default:
+				b.WriteByte(value[i])
+			}
+		case value[i] == '^' && i+1 < len(value):
+			i++
+			if value[i] == '?' {
+				b.WriteByte(0x7f)
+			} else if value[i] >= '@' && value[i] <= '~' {
+				b.WriteByte(value[i] & 0x1f)
+			} else {
+				return "", errors.New("invalid caret escape in LS_COLORS")
+			}
+		default:
+			b.WriteByte(value[i])
+		}
+	}
+	return b.String(), nil
+}
+
+// Pick the indicator code for a file of the given mode and name. nlink
+// only matters for regular files.
+func (c *Colors) codeFor(name string, mode fs.FileMode, nlink uint64) string {
+	perm := mode.Perm()
+	switch {
+	case mode.IsDir():
+		switch {
+		case mode&fs.ModeSticky != 0 && perm&0o002 != 0 && c.isSet("tw"):
+			return c.types["tw"]
+		case perm&0o002 != 0 && c.isSet("ow"):
+			return c.types["ow"]
+		case mode&fs.ModeSticky != 0 && c.isSet("st"):
+			return c.types["st"]
+		}
+		return c.types["di"]
+	case mode&fs.ModeSymlink != 0:
+		return c.types["ln"]
+	case mode&fs.ModeNamedPipe != 0:
+		return c.types["pi"]
+	case mode&fs.ModeSocket != 0:
+		return c.types["so"]
+	case mode&fs.ModeDevice != 0 && mode&fs.ModeCharDevice != 0:
+		return c.types["cd"]
+	case mode&fs.ModeDevice != 0:
+		return c.types["bd"]
+	}
+	// Regular files
+	switch {
+	case mode&fs.ModeSetuid != 0 && c.isSet("su"):
+		return c.types["su"]
+	case mode&fs.ModeSetgid != 0 && c.isSet("sg"):
+		return c.types["sg"]
+	case perm&0o111 != 0 && c.isSet("ex"):
+		return c.types["ex"]
+	case nlink > 1 && c.isSet("mh"):
+		return c.types["mh"]
+	}
+	if code, ok := c.suffixCode(name); ok {
+		return code
+	}
+	return c.types["fi"]
+}
+
+// Find the color of a name by its suffix. An exact match wins over one
+// that only matches when case is ignored; later entries win over earlier.
+func (c *Colors) suffixCode(name string) (string, bool) {
+	lowerName := strings.ToLower(name)
+	code, found, foldedCode, foldedFound := "", false, "", false
+	for _, thisSuffix := range c.extensions {
+		if strings.HasSuffix(name, thisSuffix.suffix) {
+			code, found = thisSuffix.code, true
+		} else if strings.HasSuffix(lowerName, strings.ToLower(thisSuffix.suffix)) {
+			foldedCode, foldedFound = thisSuffix.code, true
+		}
+	}
+	if found {
+		return code, true
+	}
+	return foldedCode, foldedFound
+}
+
+// Report whether a type code is set to something that changes the output
+func (c *Colors) isSet(code string) bool {
+	value := c.types[code]
+	return value != "" && value != "0" && value != "00"
+}
+
+// Wrap text in the color sequence for code. Codes that would not change
+// anything leave text alone.
+func (c *Colors) paint(code, text string) string {
+	before, after := c.wrap(code)
+	return before + text + after
+}
+
+// Return the sequences that go before and after text colored with code,
+// none when the code would not change anything
+func (c *Colors) wrap(code string) (before, after string) {
+	if code == "" || code == "0" || code == "00" {
+		return "", ""
+	}
+	end := c.types["ec"]
+	if end == "" {
+		end = c.types["lc"] + c.types["rs"] + c.types["rc"]
+	}
+	return c.types["lc"] + code + c.types["rc"], end
+}
+
+// Return the sequences coloring the name of entry. Symlinks whose target
+// is missing use "or", or their target's color when LS_COLORS sets
+// "ln=target".
+func (l *listing) nameColor(entry *Entry) (before, after string) {
+	colors := l.opts.Colors
+	if colors == nil {
+		return "", ""
+	}
+	code := colors.codeFor(entry.Name, entry.Mode, entry.Links)
+	if entry.Mode&fs.ModeSymlink != 0 {
+		switch {
+		case entry.TargetMissing && colors.isSet("or"):
+			code = colors.types["or"]
+		case code == "target" && entry.TargetMissing:
+			code = ""
+		case code == "target":
+			code = colors.codeFor(entry.Name, entry.TargetMode, 1)
+		}
+	}
+	return colors.wrap(code)
+}
+
+// Color the target of a symlink shown by the long format: by the target's
+// own type, or with "mi" when it does not exist
+func (l *listing) colorTarget(entry *Entry, text string) string {
+	colors := l.opts.Colors
+	if colors == nil {
+		return text
+	}
+	if entry.TargetMissing {
+		return colors.paint(colors.types["mi"], text)
+	}
+	return colors.paint(colors.codeFor(entry.LinkTarget, entry.TargetMode, 1), text)
+}
@@ -0,0 +1,189 @@
+package lister
+
+import (
+	"io"
+	"my-ls-1/data"
+	"strings"
+)
+
+// Format selects the layout of a listing
+type Format int
+
+const (
+	FormatOneLine Format = iota // One name per line (-1), the default
+	FormatLong                  // One row of details per entry (-l)
+	FormatColumns               // Names in columns, sorted down each column (-C)
+	FormatAcross                // Names in columns, sorted across each row (-x)
+	FormatCommas                // Names separated by ", ", filling the line (-m)
+	FormatJSON                  // One JSON document per operand (--json), see JSONSchemaVersion
+	FormatNDJSON                // One JSON object per line and entry, streamed (--ndjson)
+	FormatCSV                   // One comma separated row per entry, see Options.Columns
+	FormatTSV                   // One tab separated row per entry, see Options.Columns
+)
+
+// Space between two columns of names
+const columnGap = 2
+
+// A name ready to be printed in one of the short formats
+type cell struct {
+	text  string // What is printed
+	width int    // Screen columns taken by text
+}
+
+// Print entries in one of the short formats
+func (l *listing) printShort(entries []*Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	cells := l.shortCells(entries)
+	switch l.opts.Format {
+	case FormatColumns:
+		return l.printColumns(cells, true)
+	case FormatAcross:
+		return l.printColumns(cells, false)
+	case FormatCommas:
+		return l.printCommas(cells)
+	}
+	for _, thisCell := range cells {
+		if _, err := io.WriteString(l.w, thisCell.text+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Render every entry as its quoted name and type indicator, behind its inode and
+// block columns when -i or -s ask for them
+func (l *listing) shortCells(entries []*Entry) []cell {
+	prefixes := l.infoPrefixes(entries)
+	cells := make([]cell, 0, len(entries))
+	for i, entry := range entries {
+		text := prefixes[i] + l.displayName(entry) + l.opts.Indicator.indicator(entry.Mode)
+		cells = append(cells, cell{text: text, width: data.DisplayWidth(text)})
+	}
+	return cells
+}
+
+// Render the inode and block columns of every entry, each followed by a
+// space and right aligned to the widest value. Entries get "" when -i and
+// -s are not given.
+func (l *listing) infoPrefixes(entries []*Entry) []string {
+	widths := []int{}
+	for _, entry := range entries {
+		for i, thisPart := range l.infoColumns(entry) {
+			if len(widths) <= i {
+				widths = append(widths, 0)
+			}
+			if len(thisPart) > widths[i] {
+				widths[i] = len(thisPart)
+			}
+		}
+	}
+	prefixes := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		prefix := ""
+		for i, thisPart := range l.infoColumns(entry) {
+			prefix += strings.Repeat(" ", widths[i]-len(thisPart)) + thisPart + " "
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	return prefixes
+}
+
+// Print cells in as many columns as fit the line width. With vertical set
+// the cells run down each column (-C), otherwise across each row (-x).
+func (l *listing) printColumns(cells []cell, vertical bool) error {
+	cols, rows := 1, len(cells)
+	var widths []int
+	// Try every column count from the largest that might fit down to one;
+	// the first layout narrower than the line wins
+	maxCols := len(cells)
+	if l.opts.Width > 0 && l.opts.Width/(1+columnGap) < maxCols {
+		maxCols = l.opts.Width / (1 + columnGap)
+	}
+	for tryCols := maxCols; tryCols >= 1; tryCols-- {
+		tryWidths := columnWidths(cells, tryCols, vertical)
+		lineWidth := 0
+		for _, width := range tryWidths {
+			lineWidth += width
+		}
+		lineWidth += columnGap * (len(tryWidths) - 1)
+		if tryCols == 1 || l.opts.Width <= 0 || lineWidth < l.opts.Width {
+			cols, widths = len(tryWidths), tryWidths
+			rows = (len(cells) + tryCols - 1) / tryCols
+			break
+		}
+	}
+
+	for row := 0; row < rows; row++ {
+		line := ""
+		for col := 0; col < cols; col++ {
+			i := cellIndex(row, col, rows, cols, vertical)
+			if i >= len(cells) {
+				break
+			}
+			line += cells[i].text
+			// Pad to the column width unless this is the last cell of the line
+			next := cellIndex(row, col+1, rows, cols, vertical)
+			if col+1 < cols && next < len(cells) {
+				line += strings.Repeat(" ", widths[col]-cells[i].width+columnGap)
+			}
+		}
+		if _, err := io.WriteString(l.w, line+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Width of every column when cells are laid out in cols columns
+func columnWidths(cells []cell, cols int, vertical bool) []int {
+	rows := (len(cells) + cols - 1) / cols
+	// Vertical layouts may need fewer columns than asked for
+	if vertical {
+		cols = (len(cells) + rows - 1) / rows
+	}
+	widths := make([]int, cols)
+	for i, thisCell := range cells {
+		col := i % cols
+		if vertical {
+			col = i / rows
+		}
+		if thisCell.width > widths[col] {
+			widths[col] = thisCell.width
+		}
+	}
+	return widths
+}
+
+// Index of the cell shown at row and col
+func cellIndex(row, col, rows, cols int, vertical bool) int {
+	if vertical {
+		return col*rows + row
+	}
+	return row*cols + col
+}
+
+// Print cells separated by ", ", starting a new line before a name that
+// would not fit the line width
+func (l *listing) printCommas(cells []cell) error {
+	line := ""
+	pos := 0
+	for i, thisCell := range cells {
+		if i > 0 {
+			if l.opts.Width <= 0 || pos+columnGap+thisCell.width < l.opts.Width {
+				line += ", "
+				pos += columnGap
+			} else {
+				if _, err := io.WriteString(l.w, line+",\n"); err != nil {
+					return err
+				}
+				line, pos = "", 0
+			}
+		}
+		line += thisCell.text
+		pos += thisCell.width
+	}
+	_, err := io.WriteString(l.w, line+"\n")
+	return err
+}
@@ -0,0 +1,99 @@
+package lister
+
+import (
+	"fmt"
+	"io"
+	"my-ls-1/data"
+	"strconv"
+	"strings"
+)
+
+// Every line of a dired listing but the blank ones starts with this, as
+// Emacs expects
+const diredIndent = "  "
+
+// Names of the quoting styles as --quoting-style takes them
+var quotingStyleNames = map[QuotingStyle]string{
+	QuotingLiteral:           "literal",
+	QuotingLocale:            "locale",
+	QuotingShell:             "shell",
+	QuotingShellAlways:       "shell-always",
+	QuotingShellEscape:       "shell-escape",
+	QuotingShellEscapeAlways: "shell-escape-always",
+	QuotingC:                 "c",
+	QuotingEscape:            "escape",
+	quotingCMaybe:            "c-maybe",
+}
+
+// Byte offsets collected for the trailer of a dired listing (-D)
+type dired struct {
+	w       *countingWriter
+	names   []data.Span // Names of the listed files
+	subdirs []data.Span // Names in the directory headers
+}
+
+// A writer that counts the bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += n
+	return n, err
+}
+
+// Start a dired listing on top of w, or return nil when Options do not
+// ask for one. Like GNU ls, -D only applies to the long format, and
+// not to trees.
+func newDired(opts Options, w io.Writer) *dired {
+	if !opts.Dired || opts.Format != FormatLong || opts.Tree != TreeNone {
+		return nil
+	}
+	return &dired{w: &countingWriter{w: w}}
+}
+
+// Write a directory header, recording where its name lands
+func (l *listing) writeDiredHeader(header string) error {
+	start := l.dired.w.n + len(diredIndent)
+	if _, err := io.WriteString(l.w, diredIndent+header+":\n"); err != nil {
+		return err
+	}
+	l.dired.subdirs = append(l.dired.subdirs, data.Span{Start: start, End: start + len(header)})
+	return nil
+}
+
+// Flush the long format rows, recording where the names land
+func (l *listing) flushDired() error {
+	start := l.dired.w.n
+	err := l.fp.Flush(l.w)
+	for _, span := range l.fp.Spans() {
+		l.dired.names = append(l.dired.names, data.Span{Start: start + span.Start, End: start + span.End})
+	}
+	return err
+}
+
+// Write the trailer Emacs reads the offsets from, once everything else is
+// listed
+func (l *listing) writeDiredTrailer() error {
+	trailer := diredOffsets("//DIRED//", l.dired.names)
+	trailer += diredOffsets("//SUBDIRED//", l.dired.subdirs)
+	trailer += fmt.Sprintf("//DIRED-OPTIONS// --quoting-style=%s\n", quotingStyleNames[l.opts.Quoting])
+	_, err := io.WriteString(l.w, trailer)
+	return err
+}
+
+// Format a line of offsets, or nothing when there are none
+func diredOffsets(label string, spans []data.Span) string {
+	if len(spans) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(label)
+	for _, span := range spans {
+		b.WriteString(" " + strconv.Itoa(span.Start) + " " + strconv.Itoa(span.End))
+	}
+	b.WriteString("\n")
+	return b.String()
+}
@@ -0,0 +1,120 @@
+package lister
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// Cut out the text of every span a dired trailer line with the given
+// label points at
+func diredSpans(t *testing.T, out, label string) []string {
+	t.Helper()
+	texts := []string{}
+	for _, line := range strings.Split(out, "\n") {
+		if !strings.HasPrefix(line, label+" ") {
+			continue
+		}
+		fields := strings.Fields(strings.TrimPrefix(line, label))
+		if len(fields)%2 != 0 {
+			t.Fatalf("odd number of offsets in %q", line)
+		}
+		for i := 0; i < len(fields); i += 2 {
+			start, err1 := strconv.Atoi(fields[i])
+			end, err2 := strconv.Atoi(fields[i+1])
+			if err1 != nil || err2 != nil || start > end || end > len(out) {
+				t.Fatalf("bad span %s %s in %q", fields[i], fields[i+1], line)
+			}
+			texts = append(texts, out[start:end])
+		}
+	}
+	return texts
+}
+
+func TestDiredOffsets(t *testing.T) {
+	root := makeTree(t, "d/plain", "d/with space", `d/q"uote`, "d/it's", "d/link -> plain", "d/sub/inner", "d/sub/.hidden")
+	dir := filepath.Join(root, "d")
+	sub := filepath.Join(dir, "sub")
+	long := Options{Format: FormatLong, Dired: true}
+	tests := []struct {
+		name        string
+		opts        Options
+		paths       []string
+		wantNames   []string
+		wantSubdirs []string
+		wantQuoting string
+	}{
+		// A lone directory has no header
+		{
+			name:        "one directory",
+			opts:        long,
+			paths:       []string{dir},
+			wantNames:   []string{"it's", "link", "plain", `q"uote`, "sub", "with space"},
+			wantQuoting: "literal",
+		},
+		{
+			name:        "recursive",
+			opts:        Options{Format: FormatLong, Dired: true, Recursive: true, AlmostAll: true},
+			paths:       []string{dir},
+			wantNames:   []string{"it's", "link", "plain", `q"uote`, "sub", "with space", ".hidden", "inner"},
+			wantSubdirs: []string{dir, sub},
+			wantQuoting: "literal",
+		},
+		{
+			name:        "files and directories",
+			opts:        long,
+			paths:       []string{sub, filepath.Join(dir, "plain"), filepath.Join(dir, "link")},
+			wantNames:   []string{dir + "/link", dir + "/plain", "inner"},
+			wantSubdirs: []string{sub},
+			wantQuoting: "literal",
+		},
+		// The quotes belong to the name
+		{
+			name:        "c quoting",
+			opts:        Options{Format: FormatLong, Dired: true, Recursive: true, Quoting: QuotingC},
+			paths:       []string{dir},
+			wantNames:   []string{`"it's"`, `"link"`, `"plain"`, `"q\"uote"`, `"sub"`, `"with space"`, `"inner"`},
+			wantSubdirs: []string{`"` + dir + `"`, `"` + sub + `"`},
+			wantQuoting: "c",
+		},
+		{
+			name:        "shell escape quoting",
+			opts:        Options{Format: FormatLong, Dired: true, Quoting: QuotingShellEscape},
+			paths:       []string{dir},
+			wantNames:   []string{`"it's"`, "link", "plain", `'q"uote'`, "sub", "'with space'"},
+			wantQuoting: "shell-escape",
+		},
+		// Columns before the name and colors around it shift the offsets
+		{
+			name:        "inodes, sizes and colors",
+			opts:        Options{Format: FormatLong, Dired: true, Recursive: true, Inode: true, Size: true, Colors: DefaultColors()},
+			paths:       []string{dir},
+			wantNames:   []string{"it's", "link", "plain", `q"uote`, "sub", "with space", "inner"},
+			wantSubdirs: []string{dir, sub},
+			wantQuoting: "literal",
+		},
+	}
+	for _, test := range tests {
+		out, errs := listOutput(t, test.opts, test.paths...)
+		if errs != "" {
+			t.Errorf("%s: diagnostics %q", test.name, errs)
+		}
+		// Colors stay outside the spans
+		if names := diredSpans(t, out, "//DIRED//"); strings.Join(names, "|") != strings.Join(test.wantNames, "|") {
+			t.Errorf("%s: names %q, want %q in\n%s", test.name, names, test.wantNames, out)
+		}
+		if subdirs := diredSpans(t, out, "//SUBDIRED//"); strings.Join(subdirs, "|") != strings.Join(test.wantSubdirs, "|") {
+			t.Errorf("%s: subdirs %q, want %q", test.name, subdirs, test.wantSubdirs)
+		}
+		if want := "//DIRED-OPTIONS// --quoting-style=" + test.wantQuoting + "\n"; !strings.HasSuffix(out, want) {
+			t.Errorf("%s: output does not end with %q", test.name, want)
+		}
+		// Every line but the trailer and blank lines is indented
+		for _, line := range strings.Split(strings.TrimSuffix(out, "\n"), "\n") {
+			if line != "" && !strings.HasPrefix(line, diredIndent) && !strings.HasPrefix(line, "//") {
+				t.Errorf("%s: unindented line %q", test.name, line)
+			}
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package lister
+
+import (
+	"bytes"
+	"os"
+	"syscall"
+)
+
+// Offsets of the fields read from struct linux_dirent64
+const (
+	direntReclenOffset = 16
+	direntNameOffset   = 19
+)
+
+// Return the names in the directory at path in the order getdents(2)
+// gives them, . and .. included. os.File.ReadDir and syscall.ParseDirent
+// both drop . and .., so the records are parsed here.
+func readDirNames(path string) ([]string, error) {
+	dir, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer dir.Close()
+	fd := int(dir.Fd())
+	names := []string{}
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := syscall.ReadDirent(fd, buf)
+		if err == syscall.EINTR {
+			continue
+		}
+		if err != nil {
+			return nil, &os.PathError{Op: "getdents", Path: path, Err: err}
+		}
+		if n <= 0 {
+			return names, nil
+		}
+		order := nativeEndian()
+		for records := buf[:n]; len(records) > direntNameOffset; {
+			reclen := int(order.Uint16(records[direntReclenOffset:]))
+			if reclen <= direntNameOffset || reclen > len(records) {
+				break
+			}
+			name := records[direntNameOffset:reclen]
+			if end := bytes.IndexByte(name, 0); end >= 0 {
+				name = name[:end]
+			}
+			names = append(names, string(name))
+			records = records[reclen:]
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package lister
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// Entry holds everything the listing knows about a single file
+type Entry struct {
+	Name          string      // Name as it is printed
+	Path          string      // Path used to reach the file
+	Dev           uint64      // Device of the filesystem holding the file
+	Ino           uint64      // Inode number
+	Mode          fs.FileMode // Type and permission bits
+	Marker        string      // ACL/xattr marker printed after the mode, only read for formats showing it
+	Links         uint64      // Number of hard links
+	Uid           uint32      // Owner user id
+	Gid           uint32      // Owner group id
+	User          string      // Owner user name, empty when unknown or not looked up
+	Group         string      // Owner group name, empty when unknown or not looked up
+	Size          int64       // Size in bytes
+	Rdev          uint64      // Device numbers of a block or character device
+	Blocks        int64       // Allocated 512-byte blocks
+	ModTime       time.Time   // Last modification time
+	AccessTime    time.Time   // Last access time
+	ChangeTime    time.Time   // Last status change time
+	BirthTime     time.Time   // Creation time, zero when the filesystem does not record it or it is not needed
+	Time          time.Time   // The timestamp selected by Options.Time, shown and sorted by
+	LinkTarget    string      // Target of a symbolic link, empty otherwise
+	TargetMode    fs.FileMode // Type and permission bits of the symlink target
+	TargetMissing bool        // Whether the symlink target does not exist
+	Broken        bool        // Whether following a symlink failed; only Name, Path and the type in Mode are known
+}
+
+// TimeField selects which timestamp of a file is shown and sorted by
+type TimeField int
+
+const (
+	TimeModify TimeField = iota // Last modification, the default
+	TimeAccess                  // Last access (-u)
+	TimeChange                  // Last status change (-c)
+	TimeBirth                   // Creation, when the filesystem records it
+)
+
+// Dereference selects which symbolic links are followed, so that the
+// listing shows their target instead of the link
+type Dereference int
+
+const (
+	DereferenceNever           Dereference = iota // Links are listed themselves, the default
+	DereferenceCommandLineDirs                    // Operands linking to directories, as ls does without -l
+	DereferenceCommandLine                        // All operands (-H)
+	DereferenceAlways                             // Every link (-L)
+)
+
+// Stat path and return it as an entry printed as name, with Time set to
+// the timestamp selected by Options.Time. Symlinks are followed when follow
+// is set, so that the entry describes their target.
+func (l *listing) newEntry(path, name string, follow bool) (*Entry, error) {
+	stat := os.Lstat
+	if follow {
+		stat = os.Stat
+	}
+	info, err := stat(path)
+	if err != nil {
+		return nil, err
+	}
+	// Extended attributes and the birth time are read from the file itself,
+	// which for a followed symlink is its target
+	realPath := path
+	if follow && (l.markers || l.birthTimes) {
+		if resolved, err := filepath.EvalSymlinks(path); err == nil {
+			realPath = resolved
+		}
+	}
+	// Get the system-specific file information
+	sys := info.Sys().(*syscall.Stat_t)
+	entry := &Entry{
+		Name:    name,
+		Path:    path,
+		Dev:     uint64(sys.Dev),
+		Ino:     sys.Ino,
+		Mode:    info.Mode(),
+		Links:   uint64(sys.Nlink),
+		Uid:     sys.Uid,
+		Gid:     sys.Gid,
+		Size:    info.Size(),
+		Rdev:    uint64(sys.Rdev),
+		Blocks:  sys.Blocks,
+		ModTime: info.ModTime(),
+		// Access and change times only come with the system-specific information
+		AccessTime: time.Unix(sys.Atim.Unix()),
+		ChangeTime: time.Unix(sys.Ctim.Unix()),
+	}
+	// The birth time needs statx
+	if l.birthTimes {
+		if btime, ok := birthTime(realPath); ok {
+			entry.BirthTime = btime
+		}
+	}
+	switch l.opts.Time {
+	case TimeAccess:
+		entry.Time = entry.AccessTime
+	case TimeChange:
+		entry.Time = entry.ChangeTime
+	case TimeBirth:
+		entry.Time = entry.BirthTime
+	default:
+		entry.Time = entry.ModTime
+	}
+	if l.names != nil {
+		entry.User = l.names.user(sys.Uid)
+		entry.Group = l.names.group(sys.Gid)
+	}
+	// Get the ACL/xattr marker of the file
+	if l.markers {
+		entry.Marker = xattrMarker(realPath)
+	}
+	// Get the link name of the file, if it is a symlink
+	if info.Mode()&os.ModeSymlink != 0 {
+		entry.LinkTarget, _ = os.Readlink(path)
+		// The target decides how the link is colored
+		if target, err := os.Stat(path); err == nil {
+			entry.TargetMode = target.Mode()
+		} else {
+			entry.TargetMissing = true
+		}
+	}
+	return entry, nil
+}
+
+// Return an entry for a symlink at path that could not be followed. It
+// only knows the type of the link, like GNU ls listing "l?????????".
+func brokenEntry(path, name string) (*Entry, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Entry{Name: name, Path: path, Mode: info.Mode().Type(), Broken: true}, nil
+}
+
+// Report whether the entry is a directory
+func (e *Entry) IsDir() bool {
+	return e.Mode.IsDir()
+}
+
+// Report whether the entry is a directory or a symlink to one
+func (e *Entry) isLinkedDir() bool {
+	return e.IsDir() || (e.Mode&fs.ModeSymlink != 0 && !e.TargetMissing && e.TargetMode.IsDir())
+}
+
+// Report whether the entry is a hidden dotfile
+func (e *Entry) IsHidden() bool {
+	return len(e.Name) > 0 && e.Name[0] == '.'
+}
+
+// Join a directory and a name the way the listing prints them
+func joinPath(dir, name string) string {
+	if dir == "" {
+		return name
+	}
+	if dir[len(dir)-1] == '/' {
+		return dir + name
+	}
+	return dir + "/" + name
+}
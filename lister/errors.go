@@ -0,0 +1,67 @@
+package lister
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+)
+
+// Exit statuses with the meaning coreutils gives them
+const (
+	StatusOK      = 0 // Everything was listed
+	StatusMinor   = 1 // Some entry below a command line operand could not be listed
+	StatusSerious = 2 // A command line operand could not be listed
+)
+
+// ProblemError is returned by List when the listing ran to the end but
+// some files could not be listed. The details were already written to
+// Options.Errors as they happened.
+type ProblemError struct {
+	Status int // StatusMinor or StatusSerious
+}
+
+func (e *ProblemError) Error() string {
+	if e.Status == StatusSerious {
+		return "some command line operands could not be listed"
+	}
+	return "some files could not be listed"
+}
+
+// Report a problem to the error writer and remember how bad it was.
+// Problems with command line operands are serious, all others minor.
+func (l *listing) fail(commandLine bool, format string, a ...interface{}) {
+	if l.opts.Errors != nil {
+		msg := fmt.Sprintf(format, a...)
+		if l.opts.Program != "" {
+			msg = l.opts.Program + ": " + msg
+		}
+		fmt.Fprintln(l.opts.Errors, msg)
+	}
+	status := StatusMinor
+	if commandLine {
+		status = StatusSerious
+	}
+	if status > l.status {
+		l.status = status
+	}
+}
+
+// Quote a file name for a diagnostic
+func quoteName(name string) string {
+	return quote(name, QuotingShellEscapeAlways, "")
+}
+
+// Describe an error the way strerror does, without the operation and path
+// that os adds to it
+func describe(err error) string {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		err = pathErr.Err
+	}
+	msg := err.Error()
+	if msg == "" {
+		return msg
+	}
+	return strings.ToUpper(msg[:1]) + msg[1:]
+}
@@ -0,0 +1,128 @@
+package lister
+
+// Report whether the directory entry called name is left out of the
+// listing. Dotfiles are hidden unless -a or -A is set, and . and .. unless
+// -a is; Hide patterns only apply when neither is set, Ignore patterns
+// always do.
+func (l *listing) ignored(name string) bool {
+	showDotfiles := l.opts.All || l.opts.AlmostAll
+	if name[0] == '.' {
+		if !showDotfiles {
+			return true
+		}
+		if !l.opts.All && (name == "." || name == "..") {
+			return true
+		}
+	}
+	if !showDotfiles && matchAny(l.opts.Hide, name) {
+		return true
+	}
+	return matchAny(l.opts.Ignore, name)
+}
+
+// Report whether name matches any of the shell patterns
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matchPattern(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Match name against a shell pattern the way fnmatch(3) does with
+// FNM_PERIOD: '*' and '?' match any characters, "[...]" a set of them
+// ("[!...]" or "[^...]" its complement) and a backslash quotes the next
+// character. A leading '.' has to be matched by a literal '.'.
+func matchPattern(pattern, name string) bool {
+	if name != "" && name[0] == '.' {
+		switch {
+		case pattern != "" && pattern[0] == '.':
+		case len(pattern) > 1 && pattern[0] == '\\' && pattern[1] == '.':
+		default:
+			return false
+		}
+	}
+	return matchFrom([]rune(pattern), []rune(name))
+}
+
+// Match the rest of a pattern against the rest of a name
+func matchFrom(pattern, name []rune) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			// Try every possible length for the star, shortest first
+			for skip := 0; skip <= len(name); skip++ {
+				if matchFrom(pattern[1:], name[skip:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(name) == 0 {
+				return false
+			}
+		case '[':
+			matched, length, ok := matchBracket(pattern, name)
+			if ok {
+				if !matched {
+					return false
+				}
+				pattern, name = pattern[length:], name[1:]
+				continue
+			}
+			// An unterminated bracket is an ordinary character
+			if len(name) == 0 || name[0] != '[' {
+				return false
+			}
+		case '\\':
+			if len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+		default:
+			if len(name) == 0 || name[0] != pattern[0] {
+				return false
+			}
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// Match the first character of name against the bracket expression that
+// starts pattern. length is the size of the expression; ok is false when
+// it is not terminated.
+func matchBracket(pattern, name []rune) (matched bool, length int, ok bool) {
+	i := 1
+	negate := false
+	if i < len(pattern) && (pattern[i] == '!' || pattern[i] == '^') {
+		negate = true
+		i++
+	}
+	found := false
+	for first := true; i < len(pattern); first = false {
+		// A ']' right after the opening bracket is part of the set
+		if pattern[i] == ']' && !first {
+			if len(name) == 0 {
+				return false, i + 1, true
+			}
+			return found != negate, i + 1, true
+		}
+		lo := pattern[i]
+		if lo == '\\' && i+1 < len(pattern) {
+			i++
+			lo = pattern[i]
+		}
+		hi := lo
+		if i+2 < len(pattern) && pattern[i+1] == '-' && pattern[i+2] != ']' {
+			hi = pattern[i+2]
+			i += 2
+		}
+		if len(name) > 0 && name[0] >= lo && name[0] <= hi {
+			found = true
+		}
+		i++
+	}
+	return false, 0, false
+}
@@ -0,0 +1,104 @@
+package lister
+
+import "testing"
+
+// Results agree with glibc's fnmatch(pattern, name, FNM_PERIOD)
+func TestMatchPattern(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"*", "a", true},
+		{"*", "", true},
+		{"?", "", false},
+		{"?", "é", true},
+		{"é?", "éa", true},
+		{"*.c", "a.c", true},
+		{"*a*b", "xaxb", true},
+		{"*a*b", "xbxa", false},
+		{"*~", "a~", true},
+		// Sets and ranges
+		{"[a-c]x", "bx", true},
+		{"[a-c]x", "dx", false},
+		{"[c-a]x", "bx", false},
+		{"[a-]", "-", true},
+		{"[-a]", "-", true},
+		{"[é]", "é", true},
+		// Complements, with either character
+		{"[!a]b", "ab", false},
+		{"[!a]b", "cb", true},
+		{"[^a]b", "ab", false},
+		{"[^a]b", "xb", true},
+		{"[!a]", "", false},
+		// ']' first in a set is a member, even after '!' or '^'
+		{"[]]", "]", true},
+		{"[]a]", "a", true},
+		{"[!]]", "]", false},
+		{"[!]]", "x", true},
+		{"[^]a]x", "bx", true},
+		// An unterminated '[' is an ordinary character
+		{"[a", "[a", true},
+		{"[a", "a", false},
+		{"x[", "x[", true},
+		{"[", "[", true},
+		{"[!", "[!", true},
+		// Backslash quotes the next character, in and out of sets
+		{`\*`, "*", true},
+		{`\*`, "a", false},
+		{`a\?`, "a?", true},
+		{`a\?`, "ab", false},
+		{`[\]]`, "]", true},
+		{`[\!a]`, "!", true},
+		{`[a\-z]`, "-", true},
+		{`[a\-z]`, "b", false},
+		// A leading '.' has to be matched by a literal one
+		{"*", ".hidden", false},
+		{"?hidden", ".hidden", false},
+		{"[.]hidden", ".hidden", false},
+		{"*.c", ".c", false},
+		{".*", ".hidden", true},
+		{`\.*`, ".hidden", true},
+		{"a*", "a.b", true},
+	}
+	for _, test := range tests {
+		if got := matchPattern(test.pattern, test.name); got != test.want {
+			t.Errorf("matchPattern(%q, %q) = %v, want %v", test.pattern, test.name, got, test.want)
+		}
+	}
+}
+
+func TestIgnored(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		want []string // Names left in
+	}{
+		{"default", Options{}, []string{"a~", "b.o", "c"}},
+		{"almost all", Options{AlmostAll: true}, []string{".git", "a~", "b.o", "c"}},
+		{"all", Options{All: true}, []string{".", "..", ".git", "a~", "b.o", "c"}},
+		{"ignore", Options{Ignore: []string{"*~", "*.o"}}, []string{"c"}},
+		{"ignore with all", Options{All: true, Ignore: []string{".*"}}, []string{"a~", "b.o", "c"}},
+		// Hide patterns give way to -a and -A
+		{"hide", Options{Hide: []string{"*.o"}}, []string{"a~", "c"}},
+		{"hide with almost all", Options{AlmostAll: true, Hide: []string{"*.o"}}, []string{".git", "a~", "b.o", "c"}},
+	}
+	for _, test := range tests {
+		l := &listing{opts: test.opts}
+		got := []string{}
+		for _, name := range []string{".", "..", ".git", "a~", "b.o", "c"} {
+			if !l.ignored(name) {
+				got = append(got, name)
+			}
+		}
+		if len(got) != len(test.want) {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != test.want[i] {
+				t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package lister
+
+import "io/fs"
+
+// IndicatorStyle selects the character appended to names to show their
+// type
+type IndicatorStyle int
+
+const (
+	IndicatorNone     IndicatorStyle = iota // Bare names, the default
+	IndicatorSlash                          // '/' after directories (-p)
+	IndicatorFileType                       // Every type but executables (--file-type)
+	IndicatorClassify                       // Every type and executables (-F)
+)
+
+// Return the indicator of a file of the given mode: '/' for directories,
+// '@' for symlinks, '|' for FIFOs, '=' for sockets and '*' for executables,
+// as far as style asks for them. Doors do not exist on Linux, so '>' is
+// never printed.
+func (style IndicatorStyle) indicator(mode fs.FileMode) string {
+	if style == IndicatorNone {
+		return ""
+	}
+	if mode.IsDir() {
+		return "/"
+	}
+	if style == IndicatorSlash {
+		return ""
+	}
+	switch {
+	case mode&fs.ModeSymlink != 0:
+		return "@"
+	case mode&fs.ModeNamedPipe != 0:
+		return "|"
+	case mode&fs.ModeSocket != 0:
+		return "="
+	case mode.IsRegular() && mode.Perm()&0o111 != 0 && style == IndicatorClassify:
+		return "*"
+	}
+	return ""
+}
@@ -0,0 +1,285 @@
+package lister
+
+import (
+	"encoding/json"
+	"io/fs"
+	"strconv"
+	"syscall"
+)
+
+// JSONSchemaVersion is the version of the objects written by FormatJSON
+// and FormatNDJSON. Adding a field keeps the version; removing a field or
+// changing its meaning bumps it.
+//
+// FormatJSON writes one document per operand, in the order operands are
+// listed:
+//
+//	{"version": 1, "operand": "dir", "entry": {...}}
+//
+// Directory operands carry their contents in "entries", and so do their
+// subdirectories when listing recursively. FormatNDJSON writes the entries
+// the text formats would list, one object per line as they are read, each
+// with a "version" member of its own.
+//
+// Every entry has these members:
+//
+//	path         path used to reach the file
+//	name         name of the file in its directory, or the operand
+//	type         "file", "directory", "symlink", "fifo", "socket",
+//	             "block-device", "char-device" or "unknown"
+//	broken       true when following a symlink failed; nothing but path,
+//	             name and type is known then, and the members below are
+//	             left out
+//	mode         st_mode as a number: the type and permission bits
+//	mode_octal   permission and set-id/sticky bits in octal, as "0755"
+//	permissions  mode string of the long format, as "drwxr-xr-x"
+//	inode        inode number
+//	nlink        number of hard links
+//	uid, gid     owner and group IDs
+//	user, group  owner and group names, null when the ID has no name
+//	size         size in bytes
+//	blocks       allocated 512-byte blocks
+//	atime, mtime, ctime
+//	             access, modification and status change times in RFC 3339
+//	             with nanoseconds
+//	btime        birth time, null when the filesystem does not record it
+//	link_target  target of a symlink, only present for symlinks
+//	target_missing
+//	             true when the target of a symlink does not exist
+//	xattr        marker of the long format: "+" for an ACL, "@" for user
+//	             extended attributes, "." for an SELinux context, or ""
+//	device       {"major": 8, "minor": 1} of the filesystem holding the file
+//	rdev         {"major": 1, "minor": 3} of a device file, only present
+//	             for block and character devices
+//	entries      contents of a listed directory, JSON documents only
+//	error        why a directory could not be read, JSON documents only
+//
+// Names and paths that are not valid UTF-8 have their invalid bytes
+// replaced with U+FFFD, as encoding/json does.
+const JSONSchemaVersion = 1
+
+// Timestamps in RFC 3339, always with all nine digits of nanoseconds
+const jsonTimeFormat = "2006-01-02T15:04:05.000000000Z07:00"
+
+// A file as the JSON formats describe it
+type jsonEntry struct {
+	Path      string `json:"path"`
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	Broken    bool   `json:"broken,omitempty"`
+	*jsonStat        // Left out for broken entries
+	// Set for listed directories only, so that an empty directory can be
+	// told apart from one that was not listed
+	Entries *[]*jsonEntry `json:"entries,omitempty"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// What stat tells about a file
+type jsonStat struct {
+	Mode          uint32      `json:"mode"`
+	ModeOctal     string      `json:"mode_octal"`
+	Permissions   string      `json:"permissions"`
+	Inode         uint64      `json:"inode"`
+	Nlink         uint64      `json:"nlink"`
+	UID           uint32      `json:"uid"`
+	GID           uint32      `json:"gid"`
+	User          *string     `json:"user"`
+	Group         *string     `json:"group"`
+	Size          int64       `json:"size"`
+	Blocks        int64       `json:"blocks"`
+	Atime         string      `json:"atime"`
+	Mtime         string      `json:"mtime"`
+	Ctime         string      `json:"ctime"`
+	Btime         *string     `json:"btime"`
+	LinkTarget    string      `json:"link_target,omitempty"`
+	TargetMissing bool        `json:"target_missing,omitempty"`
+	Xattr         string      `json:"xattr"`
+	Device        jsonDevice  `json:"device"`
+	Rdev          *jsonDevice `json:"rdev,omitempty"`
+}
+
+// A device number split into its major and minor parts
+type jsonDevice struct {
+	Major uint64 `json:"major"`
+	Minor uint64 `json:"minor"`
+}
+
+// The document written for every operand by FormatJSON
+type jsonDocument struct {
+	Version int        `json:"version"`
+	Operand string     `json:"operand"`
+	Entry   *jsonEntry `json:"entry"`
+}
+
+// A line written for every entry by FormatNDJSON
+type jsonRecord struct {
+	Version int `json:"version"`
+	*jsonEntry
+}
+
+// Describe entry for the JSON formats
+func newJSONEntry(entry *Entry) *jsonEntry {
+	result := &jsonEntry{Path: entry.Path, Name: entry.Name, Type: fileType(entry.Mode), Broken: entry.Broken}
+	if entry.Broken {
+		return result
+	}
+	stat := &jsonStat{
+		Mode:          unixMode(entry.Mode),
+		ModeOctal:     "0" + strconv.FormatUint(uint64(unixMode(entry.Mode)&0o7777), 8),
+		Permissions:   modeString(entry.Mode),
+		Inode:         entry.Ino,
+		Nlink:         entry.Links,
+		UID:           entry.Uid,
+		GID:           entry.Gid,
+		Size:          entry.Size,
+		Blocks:        entry.Blocks,
+		Atime:         entry.AccessTime.Format(jsonTimeFormat),
+		Mtime:         entry.ModTime.Format(jsonTimeFormat),
+		Ctime:         entry.ChangeTime.Format(jsonTimeFormat),
+		LinkTarget:    entry.LinkTarget,
+		TargetMissing: entry.TargetMissing,
+		Xattr:         entry.Marker,
+		Device:        splitDevice(entry.Dev),
+	}
+	if entry.User != "" {
+		stat.User = &entry.User
+	}
+	if entry.Group != "" {
+		stat.Group = &entry.Group
+	}
+	if !entry.BirthTime.IsZero() {
+		btime := entry.BirthTime.Format(jsonTimeFormat)
+		stat.Btime = &btime
+	}
+	if entry.Mode&fs.ModeDevice != 0 {
+		rdev := splitDevice(entry.Rdev)
+		stat.Rdev = &rdev
+	}
+	result.jsonStat = stat
+	return result
+}
+
+// Write the JSON document of an operand, reading directories as deep as
+// the listing goes
+func (l *listing) writeDocument(operand *Entry) error {
+	root := newJSONEntry(operand)
+	if operand.IsDir() && !l.opts.Directory {
+		if err := l.jsonContents(root, operand, 0); err != nil {
+			return err
+		}
+	}
+	encoder := json.NewEncoder(l.w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(jsonDocument{Version: JSONSchemaVersion, Operand: operand.Path, Entry: root})
+}
+
+// Fill in the contents of the directory dir, described by node, and of
+// its subdirectories when listing recursively
+func (l *listing) jsonContents(node *jsonEntry, dir *Entry, depth int) error {
+	if err := l.ctx.Err(); err != nil {
+		return err
+	}
+	if !l.enterDir(dir) {
+		node.Error = "not listing already-listed directory"
+		return nil
+	}
+	defer l.leaveDir(dir)
+	names, err := l.readDir(dir.Path)
+	if err != nil {
+		l.fail(depth == 0, "cannot open directory %s: %s", quoteName(dir.Path), describe(err))
+		node.Error = describe(err)
+		return nil
+	}
+	entries := l.dirEntries(dir.Path, names)
+	l.sortEntries(entries)
+	nodes := make([]*jsonEntry, 0, len(entries))
+	children := map[*Entry]*jsonEntry{}
+	for _, entry := range entries {
+		child := newJSONEntry(entry)
+		nodes = append(nodes, child)
+		children[entry] = child
+	}
+	node.Entries = &nodes
+	for _, entry := range l.subdirs(entries, depth) {
+		if err := l.jsonContents(children[entry], entry, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Write entries as NDJSON records, one line each
+func (l *listing) writeRecords(entries []*Entry) error {
+	encoder := json.NewEncoder(l.w)
+	encoder.SetEscapeHTML(false)
+	for _, entry := range entries {
+		if err := encoder.Encode(jsonRecord{Version: JSONSchemaVersion, jsonEntry: newJSONEntry(entry)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Report whether the format writes JSON
+func (f Format) isJSON() bool {
+	return f == FormatJSON || f == FormatNDJSON
+}
+
+// Name the type of a file in the JSON formats
+func fileType(mode fs.FileMode) string {
+	switch mode.Type() {
+	case 0:
+		return "file"
+	case fs.ModeDir:
+		return "directory"
+	case fs.ModeSymlink:
+		return "symlink"
+	case fs.ModeNamedPipe:
+		return "fifo"
+	case fs.ModeSocket:
+		return "socket"
+	case fs.ModeDevice:
+		return "block-device"
+	case fs.ModeDevice | fs.ModeCharDevice:
+		return "char-device"
+	}
+	return "unknown"
+}
+
+// Convert mode back to the st_mode bits of stat(2)
+func unixMode(mode fs.FileMode) uint32 {
+	bits := uint32(mode.Perm())
+	if mode&fs.ModeSetuid != 0 {
+		bits |= syscall.S_ISUID
+	}
+	if mode&fs.ModeSetgid != 0 {
+		bits |= syscall.S_ISGID
+	}
+	if mode&fs.ModeSticky != 0 {
+		bits |= syscall.S_ISVTX
+	}
+	switch mode.Type() {
+	case 0:
+		bits |= syscall.S_IFREG
+	case fs.ModeDir:
+		bits |= syscall.S_IFDIR
+	case fs.ModeSymlink:
+		bits |= syscall.S_IFLNK
+	case fs.ModeNamedPipe:
+		bits |= syscall.S_IFIFO
+	case fs.ModeSocket:
+		bits |= syscall.S_IFSOCK
+	case fs.ModeDevice:
+		bits |= syscall.S_IFBLK
+	case fs.ModeDevice | fs.ModeCharDevice:
+		bits |= syscall.S_IFCHR
+	}
+	return bits
+}
+
+// Split a device number into its major and minor parts
+func splitDevice(dev uint64) jsonDevice {
+	major, minor := deviceNumbers(dev)
+	return jsonDevice{Major: major, Minor: minor}
+}
@@ -0,0 +1,179 @@
+package lister
+
+import (
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Members every entry has, as documented along with JSONSchemaVersion
+var jsonBaseMembers = []string{"path", "name", "type"}
+
+// Members of entries that are not broken
+var jsonStatMembers = []string{"mode", "mode_octal", "permissions", "inode", "nlink", "uid", "gid",
+	"user", "group", "size", "blocks", "atime", "mtime", "ctime", "btime", "xattr", "device"}
+
+// Check that a decoded entry has the members the schema promises, and
+// only those. listed tells whether FormatJSON read the contents of a
+// directory into it.
+func checkJSONEntry(t *testing.T, entry map[string]interface{}, listed bool) {
+	t.Helper()
+	want := append([]string{}, jsonBaseMembers...)
+	if entry["broken"] == true {
+		want = append(want, "broken")
+	} else {
+		want = append(want, jsonStatMembers...)
+		if entry["type"] == "symlink" {
+			want = append(want, "link_target")
+			if entry["target_missing"] == true {
+				want = append(want, "target_missing")
+			}
+		}
+		for _, member := range []string{"atime", "mtime", "ctime", "btime"} {
+			if value, ok := entry[member].(string); ok {
+				if _, err := time.Parse(time.RFC3339Nano, value); err != nil {
+					t.Errorf("%v: %s: %v", entry["path"], member, err)
+				}
+			} else if member != "btime" || entry[member] != nil {
+				t.Errorf("%v: %s is %v", entry["path"], member, entry[member])
+			}
+		}
+		mode := uint32(entry["mode"].(float64))
+		if octal := "0" + strconv.FormatUint(uint64(mode&0o7777), 8); entry["mode_octal"] != octal {
+			t.Errorf("%v: mode_octal %v, want %s", entry["path"], entry["mode_octal"], octal)
+		}
+	}
+	if listed {
+		want = append(want, "entries")
+	}
+	got := []string{}
+	for member := range entry {
+		got = append(got, member)
+	}
+	sort.Strings(got)
+	sort.Strings(want)
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("%v: members %v, want %v", entry["path"], got, want)
+	}
+}
+
+// Names of the entries of a decoded directory
+func jsonNames(entry map[string]interface{}) []string {
+	names := []string{}
+	entries, _ := entry["entries"].([]interface{})
+	for _, child := range entries {
+		names = append(names, child.(map[string]interface{})["name"].(string))
+	}
+	return names
+}
+
+func TestJSONDocument(t *testing.T) {
+	root := makeTree(t, "d/file", "d/link -> file", "d/dangling -> missing", "d/sub/deep", "d/.hidden", "plain")
+	tests := []struct {
+		name      string
+		opts      Options
+		wantNames []string
+		wantSub   []string // Entries of d/sub, nil when it is not listed
+		wantError bool
+	}{
+		{"contents", Options{Format: FormatJSON}, []string{"dangling", "file", "link", "sub"}, nil, false},
+		{"all", Options{Format: FormatJSON, AlmostAll: true}, []string{".hidden", "dangling", "file", "link", "sub"}, nil, false},
+		{"recursive", Options{Format: FormatJSON, Recursive: true}, []string{"dangling", "file", "link", "sub"}, []string{"deep"}, false},
+		{"max depth", Options{Format: FormatJSON, Recursive: true, MaxDepth: 1}, []string{"dangling", "file", "link", "sub"}, nil, false},
+		// A symlink that cannot be followed is broken, not left out
+		{"dereference", Options{Format: FormatJSON, Dereference: DereferenceAlways}, []string{"dangling", "file", "link", "sub"}, nil, true},
+	}
+	for _, test := range tests {
+		out, errs := listOutput(t, test.opts, filepath.Join(root, "d"), filepath.Join(root, "plain"))
+		if (errs != "") != test.wantError {
+			t.Errorf("%s: diagnostics %q", test.name, errs)
+		}
+		decoder := json.NewDecoder(strings.NewReader(out))
+		operands := []string{}
+		for {
+			var document map[string]interface{}
+			if err := decoder.Decode(&document); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("%s: %v in %s", test.name, err, out)
+			}
+			if len(document) != 3 || document["version"] != float64(JSONSchemaVersion) {
+				t.Errorf("%s: document %v", test.name, document)
+			}
+			operand, _ := document["operand"].(string)
+			operands = append(operands, filepath.Base(operand))
+			entry := document["entry"].(map[string]interface{})
+			if entry["path"] != operand {
+				t.Errorf("%s: entry path %v, operand %s", test.name, entry["path"], operand)
+			}
+			checkJSONEntry(t, entry, entry["type"] == "directory")
+			if entry["type"] != "directory" {
+				continue
+			}
+			if got := jsonNames(entry); strings.Join(got, " ") != strings.Join(test.wantNames, " ") {
+				t.Errorf("%s: entries %v, want %v", test.name, got, test.wantNames)
+			}
+			for _, child := range entry["entries"].([]interface{}) {
+				child := child.(map[string]interface{})
+				isSub := child["name"] == "sub"
+				checkJSONEntry(t, child, isSub && test.wantSub != nil)
+				if isSub && strings.Join(jsonNames(child), " ") != strings.Join(test.wantSub, " ") {
+					t.Errorf("%s: sub holds %v, want %v", test.name, jsonNames(child), test.wantSub)
+				}
+				if child["name"] == "dangling" && (child["broken"] == true) != test.wantError {
+					t.Errorf("%s: dangling link %v", test.name, child)
+				}
+				if child["name"] == "file" && child["size"] != float64(len("d/file")) {
+					t.Errorf("%s: file size %v", test.name, child["size"])
+				}
+			}
+		}
+		if strings.Join(operands, " ") != "plain d" {
+			t.Errorf("%s: operands %v, want files before directories", test.name, operands)
+		}
+	}
+}
+
+func TestNDJSONRecords(t *testing.T) {
+	root := makeTree(t, "d/file", "d/link -> file", "d/dangling -> missing", "d/sub/deep", "d/.hidden")
+	tests := []struct {
+		name      string
+		opts      Options
+		wantPaths []string
+	}{
+		{"contents", Options{Format: FormatNDJSON}, []string{"d/dangling", "d/file", "d/link", "d/sub"}},
+		{"recursive", Options{Format: FormatNDJSON, Recursive: true}, []string{"d/dangling", "d/file", "d/link", "d/sub", "d/sub/deep"}},
+		{"all", Options{Format: FormatNDJSON, All: true}, []string{"d/.", "d/..", "d/.hidden", "d/dangling", "d/file", "d/link", "d/sub"}},
+		{"directory", Options{Format: FormatNDJSON, Directory: true}, []string{"d"}},
+	}
+	for _, test := range tests {
+		out, _ := listOutput(t, test.opts, filepath.Join(root, "d"))
+		paths := []string{}
+		for _, line := range strings.SplitAfter(out, "\n") {
+			if line == "" {
+				continue
+			}
+			if !strings.HasSuffix(line, "\n") {
+				t.Errorf("%s: unterminated record %q", test.name, line)
+			}
+			var record map[string]interface{}
+			if err := json.Unmarshal([]byte(line), &record); err != nil {
+				t.Fatalf("%s: %v in %q", test.name, err, line)
+			}
+			if record["version"] != float64(JSONSchemaVersion) {
+				t.Errorf("%s: version %v", test.name, record["version"])
+			}
+			delete(record, "version")
+			checkJSONEntry(t, record, false)
+			paths = append(paths, strings.TrimPrefix(record["path"].(string), root+"/"))
+		}
+		if strings.Join(paths, " ") != strings.Join(test.wantPaths, " ") {
+			t.Errorf("%s: records %v, want %v", test.name, paths, test.wantPaths)
+		}
+	}
+}
@@ -0,0 +1,331 @@
+// Package lister implements the directory listing engine behind my-ls-1.
+// All state lives in the values passed to List, so several listings can
+// run side by side in the same process.
+package lister
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"my-ls-1/data"
+	"os"
+	"time"
+)
+
+// State of one call to List
+type listing struct {
+	ctx        context.Context
+	opts       Options
+	w          io.Writer
+	fp         data.PrintFormat
+	table      *csv.Writer     // Writer of the CSV and TSV formats
+	dired      *dired          // Offsets of a dired listing (-D), nil otherwise
+	now        time.Time       // Reference time deciding which timestamps are recent
+	printed    bool            // Whether anything has been written to w yet
+	padNames   bool            // Whether unquoted names get a space to line up with quoted ones
+	minorWidth int             // Width the minor device numbers are padded to in the long format
+	names      *idNames        // Cache of owner and group names, nil when they are not shown
+	markers    bool            // Whether entries read their ACL/xattr marker
+	birthTimes bool            // Whether entries read their birth time
+	active     map[fileID]bool // Directories being listed by -R, to detect loops
+	operandDev uint64          // Device of the operand being listed by -R
+	status     int             // Worst problem met so far, one of the Status constants
+}
+
+// List writes the listing of paths to w. Operands that are files are
+// listed first, followed by the contents of every directory operand.
+// An empty paths lists the current directory.
+//
+// Files that cannot be listed are reported to opts.Errors and skipped;
+// List then returns a *ProblemError once everything else is listed.
+// Errors writing to w stop the listing and are returned as they are.
+func List(ctx context.Context, paths []string, opts Options, w io.Writer) error {
+	l := &listing{
+		ctx:    ctx,
+		opts:   opts,
+		w:      w,
+		fp:     newLongFormat(opts),
+		now:    time.Now(),
+		active: map[fileID]bool{},
+	}
+	// Owner and group names are only needed by the long format and the
+	// tables, and by JSON which always carries them
+	if ((opts.Format == FormatLong || opts.Format.isTable()) && !opts.NumericIDs) || opts.Format.isJSON() {
+		l.names = newIDNames()
+	}
+	// The marker and the birth time cost system calls of their own, so they
+	// are only read when the output shows them
+	l.markers = opts.Format == FormatLong || opts.Format.isJSON() || l.showsColumn("mode", "xattr")
+	l.birthTimes = opts.Time == TimeBirth || opts.Format.isJSON() || l.showsColumn("btime")
+	if opts.Format.isTable() {
+		l.table = newTableWriter(l)
+		if !opts.OmitHeader {
+			if err := l.writeTableHeader(); err != nil {
+				return err
+			}
+		}
+	}
+	// Dired listings count the bytes they write and indent every row
+	if l.dired = newDired(opts, w); l.dired != nil {
+		l.w = l.dired.w
+		l.fp.SetPrefix(diredIndent)
+	}
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	files := []*Entry{}
+	dirs := []*Entry{}
+	// Stat every operand and split them into files and directories
+	for _, thisArg := range paths {
+		entry, err := l.operandEntry(thisArg)
+		if err != nil {
+			l.fail(true, "cannot access %s: %s", quoteName(thisArg), describe(err))
+			continue
+		}
+		if entry.IsDir() && !opts.Directory {
+			dirs = append(dirs, entry)
+		} else {
+			files = append(files, entry)
+		}
+	}
+
+	l.sortEntries(files)
+	l.sortEntries(dirs)
+	if l.drawsTree() {
+		if err := l.listTrees(files, dirs); err != nil {
+			return err
+		}
+		return l.result()
+	}
+	if opts.Format == FormatJSON {
+		// Every operand is a document of its own, directories holding
+		// their contents
+		for _, entry := range append(files, dirs...) {
+			l.operandDev = entry.Dev
+			if err := l.writeDocument(entry); err != nil {
+				return err
+			}
+		}
+		return l.result()
+	}
+	if len(files) > 0 {
+		if err := l.printEntries(files, false); err != nil {
+			return err
+		}
+		l.printed = true
+	}
+	// Directory headers are needed as soon as there is more than one block,
+	// counting operands that could not be accessed
+	printHeader := len(paths) > 1 || opts.Recursive
+	for _, dir := range dirs {
+		l.operandDev = dir.Dev
+		if err := l.listDir(dir, printHeader, 0); err != nil {
+			return err
+		}
+	}
+	if l.dired != nil {
+		if err := l.writeDiredTrailer(); err != nil {
+			return err
+		}
+	}
+	return l.result()
+}
+
+// Return the error List ends with: a *ProblemError when some files could
+// not be listed
+func (l *listing) result() error {
+	if l.status != StatusOK {
+		return &ProblemError{Status: l.status}
+	}
+	return nil
+}
+
+// Stat a command line operand, following it as opts.Dereference says
+func (l *listing) operandEntry(path string) (*Entry, error) {
+	switch l.opts.Dereference {
+	case DereferenceCommandLine, DereferenceAlways:
+		return l.newEntry(path, path, true)
+	case DereferenceCommandLineDirs:
+		// A link to a directory is listed as the directory; any other
+		// link, even a dangling one, as the link itself
+		info, err := os.Stat(path)
+		if err == nil && info.IsDir() {
+			return l.newEntry(path, path, true)
+		}
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+	return l.newEntry(path, path, false)
+}
+
+// A file as the kernel identifies it
+type fileID struct {
+	dev, ino uint64
+}
+
+// List the contents of the directory dir, then descend into its
+// subdirectories when listing recursively. depth counts the levels below
+// the operand, which is at depth 0.
+func (l *listing) listDir(dir *Entry, printHeader bool, depth int) error {
+	if err := l.ctx.Err(); err != nil {
+		return err
+	}
+	path, commandLine := dir.Path, depth == 0
+	if !l.enterDir(dir) {
+		return nil
+	}
+	defer l.leaveDir(dir)
+	names, err := l.readDir(path)
+	if err != nil {
+		l.fail(commandLine, "cannot open directory %s: %s", quoteName(path), describe(err))
+		return nil
+	}
+	// Records streamed as NDJSON or table rows carry their path instead of
+	// being grouped in blocks under a header
+	if l.opts.Format == FormatNDJSON || l.opts.Format.isTable() {
+		printHeader = false
+	} else if l.printed {
+		// Separate this block from whatever was printed before it
+		if _, err := fmt.Fprintln(l.w); err != nil {
+			return err
+		}
+	}
+	l.printed = true
+	if printHeader {
+		// Colons in the name could be mistaken for the end of the header
+		header, _ := l.showName(path, ":")
+		if l.dired != nil {
+			if err := l.writeDiredHeader(header); err != nil {
+				return err
+			}
+		} else if _, err := fmt.Fprintln(l.w, header+":"); err != nil {
+			return err
+		}
+	}
+	// Problems with single entries are reported below the header, as
+	// GNU ls does
+	entries := l.dirEntries(path, names)
+	l.sortEntries(entries)
+	if err := l.printEntries(entries, true); err != nil {
+		return err
+	}
+
+	for _, entry := range l.subdirs(entries, depth) {
+		if err := l.listDir(entry, true, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Mark dir as being listed. A directory that contains itself, through
+// symlinks followed by -L or through bind mounts, would be listed forever,
+// so entering one that is already being listed is reported and refused.
+func (l *listing) enterDir(dir *Entry) bool {
+	id := fileID{dir.Dev, dir.Ino}
+	if l.active[id] {
+		l.fail(true, "%s: not listing already-listed directory", quote(dir.Path, QuotingShellEscape, ":"))
+		return false
+	}
+	l.active[id] = true
+	return true
+}
+
+// Mark dir as listed, once its subdirectories are done
+func (l *listing) leaveDir(dir *Entry) {
+	delete(l.active, fileID{dir.Dev, dir.Ino})
+}
+
+// Return the entries of a directory at depth that recursion descends
+// into: none unless listing recursively and above the maximum depth
+func (l *listing) subdirs(entries []*Entry, depth int) []*Entry {
+	if !l.opts.Recursive || (l.opts.MaxDepth > 0 && depth+1 >= l.opts.MaxDepth) {
+		return nil
+	}
+	subdirs := []*Entry{}
+	// Never go back up through . and ..
+	for _, entry := range entries {
+		if !entry.IsDir() || entry.Name == "." || entry.Name == ".." {
+			continue
+		}
+		// Mount points are listed but not entered with --one-file-system
+		if l.opts.OneFileSystem && entry.Dev != l.operandDev {
+			continue
+		}
+		subdirs = append(subdirs, entry)
+	}
+	return subdirs
+}
+
+// Read the names in a directory that are not ignored. This is the one
+// place where entries are filtered, for every format and for -R.
+func (l *listing) readDir(path string) ([]string, error) {
+	// Read without sorting, so -U can keep the directory order
+	all, err := readDirNames(path)
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, name := range all {
+		if !l.ignored(name) {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// Stat the files named in the directory at path
+func (l *listing) dirEntries(path string, names []string) []*Entry {
+	entries := make([]*Entry, 0, len(names))
+	for _, name := range names {
+		entry, err := l.newEntry(joinPath(path, name), name, l.opts.Dereference == DereferenceAlways)
+		if err != nil {
+			// The file may have been removed since the directory was read,
+			// or be a symlink that cannot be followed. Like GNU ls, the
+			// message leaves out a leading "./".
+			shownPath := joinPath(path, name)
+			if path == "." {
+				shownPath = name
+			}
+			l.fail(false, "cannot access %s: %s", quoteName(shownPath), describe(err))
+			if entry, err = brokenEntry(joinPath(path, name), name); err != nil {
+				continue
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// Print entries in the selected format. The "total" line is only printed
+// for directory contents, in the long format or with -s.
+func (l *listing) printEntries(entries []*Entry, withTotal bool) error {
+	switch {
+	case l.opts.Format == FormatNDJSON:
+		return l.writeRecords(entries)
+	case l.opts.Format.isTable():
+		return l.writeRows(entries)
+	}
+	if withTotal && (l.opts.Format == FormatLong || l.opts.Size) {
+		if err := l.blockSize(entries); err != nil {
+			return err
+		}
+	}
+	l.padNames = l.someQuoted(entries)
+	if l.opts.Format != FormatLong {
+		return l.printShort(entries)
+	}
+	l.minorWidth = minorWidthOf(entries)
+	for _, entry := range entries {
+		l.fp.AddMarkedRow(l.longRow(entry))
+	}
+	if l.dired != nil {
+		return l.flushDired()
+	}
+	return l.fp.Flush(l.w)
+}
@@ -0,0 +1,81 @@
+package lister
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Create files in a new temporary directory and return its path. Names
+// ending in "/" are directories and "name -> target" makes a symlink;
+// parent directories are created as needed.
+func makeTree(t *testing.T, names ...string) string {
+	t.Helper()
+	root := t.TempDir()
+	for _, name := range names {
+		link, target, isLink := strings.Cut(name, " -> ")
+		path := filepath.Join(root, link)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		var err error
+		switch {
+		case isLink:
+			err = os.Symlink(target, path)
+		case strings.HasSuffix(name, "/"):
+			err = os.MkdirAll(path, 0o755)
+		default:
+			err = os.WriteFile(path, []byte(name), 0o644)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+// List paths with opts and return the output and the diagnostics
+func listOutput(t *testing.T, opts Options, paths ...string) (string, string) {
+	t.Helper()
+	var out, errs bytes.Buffer
+	opts.Errors = &errs
+	if err := List(context.Background(), paths, opts, &out); err != nil {
+		var problem *ProblemError
+		if !errors.As(err, &problem) {
+			t.Fatalf("List: %v", err)
+		}
+	}
+	return out.String(), errs.String()
+}
+
+func TestDiagnostics(t *testing.T) {
+	root := makeTree(t, "d/dangling -> missing")
+	missing := root + "/missing"
+	tests := []struct {
+		name    string
+		program string
+		paths   []string
+		want    string
+		status  int
+	}{
+		{"operand", "ls", []string{missing}, "ls: cannot access '" + missing + "': No such file or directory\n", StatusSerious},
+		{"no program", "", []string{missing}, "cannot access '" + missing + "': No such file or directory\n", StatusSerious},
+		{"entry", "ls", []string{root + "/d"}, "ls: cannot access '" + root + "/d/dangling': No such file or directory\n", StatusMinor},
+	}
+	for _, test := range tests {
+		var out, errs bytes.Buffer
+		opts := Options{Errors: &errs, Program: test.program, Dereference: DereferenceAlways}
+		err := List(context.Background(), test.paths, opts, &out)
+		var problem *ProblemError
+		if !errors.As(err, &problem) || problem.Status != test.status {
+			t.Errorf("%s: got %v, want status %d", test.name, err, test.status)
+		}
+		if errs.String() != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, errs.String(), test.want)
+		}
+	}
+}
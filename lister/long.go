@@ -0,0 +1,117 @@
+package lister
+
+import (
+	"fmt"
+	"io/fs"
+	"my-ls-1/data"
+	"strconv"
+	"time"
+)
+
+// Create the printer used for long listings
+func newLongFormat(opts Options) data.PrintFormat {
+	alignFormat := []string{"l", "r"} // Define the alignment format for format printing
+	minWidth := []int{11, 1}          // Define the minimum width for format printing
+	// The owner and group columns can be left out
+	if !opts.OmitOwner {
+		alignFormat = append(alignFormat, "l")
+	}
+	if !opts.OmitGroup {
+		alignFormat = append(alignFormat, "l")
+	}
+	alignFormat = append(alignFormat, "r", "l", "l")
+	// The inode and block columns go in front, right aligned
+	for i := len(infoColumnsOf(opts)); i > 0; i-- {
+		alignFormat = append([]string{"r"}, alignFormat...)
+		minWidth = append([]int{0}, minWidth...)
+	}
+	return data.FormatPrint(1, alignFormat, minWidth) // Create a format printer using the defined alignment and width
+}
+
+// Names of the optional columns printed before every entry, in order
+func infoColumnsOf(opts Options) []string {
+	columns := []string{}
+	if opts.Inode {
+		columns = append(columns, "inode")
+	}
+	if opts.Size {
+		columns = append(columns, "blocks")
+	}
+	return columns
+}
+
+// Values of the optional inode (-i) and allocated size (-s) columns of entry
+func (l *listing) infoColumns(entry *Entry) []string {
+	values := []string{}
+	for _, column := range infoColumnsOf(l.opts) {
+		switch {
+		case entry.Broken:
+			values = append(values, "?")
+		case column == "inode":
+			values = append(values, strconv.FormatUint(entry.Ino, 10))
+		case column == "blocks":
+			values = append(values, l.opts.BlockSize.format(entry.Blocks*512, 1024))
+		}
+	}
+	return values
+}
+
+// Print the total number of blocks used by entries
+func (l *listing) blockSize(entries []*Entry) error {
+	totalBlocksize := int64(0)
+	for _, entry := range entries {
+		totalBlocksize += entry.Blocks
+	}
+	// Blocks are counted in 512 bytes and shown in 1024-byte units by default
+	indent := ""
+	if l.dired != nil {
+		indent = diredIndent
+	}
+	_, err := fmt.Fprintln(l.w, indent+"total", l.opts.BlockSize.format(totalBlocksize*512, 1024))
+	return err
+}
+
+// Build the tab separated long format row for entry, returning where the
+// quoted name starts and ends in it
+func (l *listing) longRow(entry *Entry) (row string, nameStart, nameEnd int) {
+	prefix := ""
+	for _, thisPart := range l.infoColumns(entry) {
+		prefix += thisPart + "\t"
+	}
+	name, nameStart, nameEnd := l.displayNameSpan(entry)
+	if entry.LinkTarget != "" {
+		// The link itself goes without an indicator; its target is classified
+		target, _ := l.showName(entry.LinkTarget, l.filenameQuoting())
+		name += " -> " + l.colorTarget(entry, target)
+		if !entry.TargetMissing {
+			name += l.opts.Indicator.indicator(entry.TargetMode)
+		}
+	} else if entry.Mode&fs.ModeSymlink == 0 {
+		name += l.opts.Indicator.indicator(entry.Mode)
+	}
+	mode := modeString(entry.Mode) + entry.Marker
+	links := strconv.FormatUint(entry.Links, 10)
+	size := l.sizeColumn(entry)
+	if entry.Broken {
+		// Nothing but the type of a link that cannot be followed is known
+		mode, links, size = mode[:1]+"?????????", "?", "?"
+	}
+	owners := ""
+	if !l.opts.OmitOwner {
+		owners += l.ownerColumn(entry) + "\t "
+	}
+	if !l.opts.OmitGroup {
+		owners += l.groupColumn(entry) + "\t "
+	}
+	row = prefix + mode + "\t" + links + "\t" + owners + size + "\t" + l.dateColumn(entry.Time) + "\t"
+	return row + name, len(row) + nameStart, len(row) + nameEnd
+}
+
+// Format the date column with the selected time style. A timestamp the
+// filesystem does not record is shown as "?".
+func (l *listing) dateColumn(fileTime time.Time) string {
+	if fileTime.IsZero() {
+		return "?"
+	}
+	return l.opts.TimeStyle.format(fileTime, l.now)
+}
@@ -0,0 +1,95 @@
+package lister
+
+import (
+	"fmt"
+	"io/fs"
+	"strconv"
+)
+
+// Render mode the way ls does: a type letter followed by three rwx
+// triplets, with s, S, t and T for the set-id and sticky bits
+func modeString(mode fs.FileMode) string {
+	typeLetter := byte('?')
+	switch mode.Type() {
+	case 0:
+		typeLetter = '-'
+	case fs.ModeDir:
+		typeLetter = 'd'
+	case fs.ModeSymlink:
+		typeLetter = 'l'
+	case fs.ModeNamedPipe:
+		typeLetter = 'p'
+	case fs.ModeSocket:
+		typeLetter = 's'
+	case fs.ModeDevice:
+		typeLetter = 'b'
+	case fs.ModeDevice | fs.ModeCharDevice:
+		typeLetter = 'c'
+	}
+	b := []byte{typeLetter}
+	for shift := 6; shift >= 0; shift -= 3 {
+		bits := mode.Perm() >> shift
+		for i, letter := range "rwx" {
+			if bits&(4>>i) != 0 {
+				b = append(b, byte(letter))
+			} else {
+				b = append(b, '-')
+			}
+		}
+	}
+	// The special bits replace the execute letters of their triplet
+	special := func(pos int, set bool, letter byte) {
+		if !set {
+			return
+		}
+		if b[pos] == 'x' {
+			b[pos] = letter
+		} else {
+			b[pos] = letter - 'a' + 'A'
+		}
+	}
+	special(3, mode&fs.ModeSetuid != 0, 's')
+	special(6, mode&fs.ModeSetgid != 0, 's')
+	special(9, mode&fs.ModeSticky != 0, 't')
+	return string(b)
+}
+
+// Split a device number into its major and minor parts, as the Linux
+// major() and minor() macros do
+func deviceNumbers(dev uint64) (major, minor uint64) {
+	major = (dev>>8)&0xfff | (dev>>32)&0xfffff000
+	minor = dev&0xff | (dev>>12)&0xffffff00
+	return major, minor
+}
+
+// Show the device numbers of a block or character device as "major, minor",
+// the minor number right aligned in minorWidth columns as GNU ls does
+func deviceColumn(rdev uint64, minorWidth int) string {
+	major, minor := deviceNumbers(rdev)
+	return fmt.Sprintf("%d, %*d", major, minorWidth, minor)
+}
+
+// Width of the widest minor number among the device files in entries, so
+// that the major numbers line up in the right aligned size column
+func minorWidthOf(entries []*Entry) int {
+	width := 0
+	for _, entry := range entries {
+		if entry.Mode&fs.ModeDevice == 0 || entry.Broken {
+			continue
+		}
+		_, minor := deviceNumbers(entry.Rdev)
+		if n := len(strconv.FormatUint(minor, 10)); n > width {
+			width = n
+		}
+	}
+	return width
+}
+
+// Text of the size column: the size in the selected units, or the device
+// numbers of a device file, which has no size
+func (l *listing) sizeColumn(entry *Entry) string {
+	if entry.Mode&fs.ModeDevice != 0 {
+		return deviceColumn(entry.Rdev, l.minorWidth)
+	}
+	return l.opts.BlockSize.format(entry.Size, 1)
+}
@@ -0,0 +1,47 @@
+package lister
+
+import "io"
+
+// Options controls what List prints. The zero value lists one name per
+// line, sorted by name and without hidden files, like ls into a pipe.
+type Options struct {
+	Format                Format         // Layout of the listing, one name per line unless set
+	Width                 int            // Line width that -C, -x and -m fill; 0 means no limit
+	Recursive             bool           // -R: descend into subdirectories
+	Directory             bool           // -d: list directory operands themselves, not their contents; overrides Recursive
+	OneFileSystem         bool           // Do not descend into directories on other filesystems
+	MaxDepth              int            // Levels of a tree listed below each operand, 1 for its contents only; 0 means no limit
+	Tree                  TreeStyle      // --tree: draw each operand as a tree, going all the way down; none unless set
+	Dereference           Dereference    // Which symlinks are followed, none unless set
+	All                   bool           // -a: include entries starting with '.', plus . and ..
+	AlmostAll             bool           // -A: include entries starting with '.', but not . and ..
+	Ignore                []string       // -I: shell patterns of names never listed
+	Hide                  []string       // --hide: shell patterns of names not listed unless All or AlmostAll is set
+	Reverse               bool           // -r: reverse the sort order
+	Inode                 bool           // -i: print the inode number of every entry
+	Size                  bool           // -s: print the allocated size of every entry
+	NumericIDs            bool           // -n: show owners and groups by ID in the long format
+	OmitOwner             bool           // -g: leave the owner out of the long format
+	OmitGroup             bool           // -o, -G: leave the group out of the long format
+	Sort                  SortKey        // Order of the entries, by name unless set
+	GroupDirectoriesFirst bool           // List directories and links to them before other files, unless Sort is SortNone
+	Time                  TimeField      // Timestamp shown by -l and sorted by -t
+	TimeStyle             TimeStyle      // How -l prints timestamps
+	BlockSize             BlockSize      // Units of the size column and the "total" line
+	Colors                *Colors        // Colors for names, nil to print them plain
+	Indicator             IndicatorStyle // Type indicator appended to names (-F, -p)
+	Quoting               QuotingStyle   // How names are quoted and escaped
+	Columns               []string       // Columns of the CSV and TSV formats, from TableColumns; those of -l when empty
+	OmitHeader            bool           // Leave out the header row of the CSV and TSV formats
+	Dired                 bool           // -D: end a long listing with the byte offsets of names, for Emacs
+	// HideControl prints characters that cannot be shown as '?' in the
+	// literal and shell quoting styles (-q)
+	HideControl bool
+
+	// Errors receives a diagnostic line for every file that cannot be
+	// listed. A nil Errors discards them.
+	Errors io.Writer
+	// Program starts every diagnostic line, as in "ls: cannot access...",
+	// when it is not empty
+	Program string
+}
@@ -0,0 +1,66 @@
+package lister
+
+import (
+	"os/user"
+	"strconv"
+)
+
+// Names of users and groups, looked up once per ID so that large
+// directories do not ask NSS about every file
+type idNames struct {
+	users  map[uint32]string
+	groups map[uint32]string
+}
+
+func newIDNames() *idNames {
+	return &idNames{users: map[uint32]string{}, groups: map[uint32]string{}}
+}
+
+// Return the name of the user with the given ID, or "" when there is no
+// such user, as is common for files in container volumes
+func (n *idNames) user(uid uint32) string {
+	name, ok := n.users[uid]
+	if !ok {
+		if usr, err := user.LookupId(strconv.FormatUint(uint64(uid), 10)); err == nil {
+			name = usr.Username
+		}
+		n.users[uid] = name
+	}
+	return name
+}
+
+// Return the name of the group with the given ID, or "" when there is no
+// such group
+func (n *idNames) group(gid uint32) string {
+	name, ok := n.groups[gid]
+	if !ok {
+		if group, err := user.LookupGroupId(strconv.FormatUint(uint64(gid), 10)); err == nil {
+			name = group.Name
+		}
+		n.groups[gid] = name
+	}
+	return name
+}
+
+// Owner of entry as the long format shows it: its name, or its ID when
+// names are not wanted or not known
+func (l *listing) ownerColumn(entry *Entry) string {
+	if entry.Broken {
+		return "?"
+	}
+	if l.opts.NumericIDs || entry.User == "" {
+		return strconv.FormatUint(uint64(entry.Uid), 10)
+	}
+	return entry.User
+}
+
+// Group of entry as the long format shows it
+func (l *listing) groupColumn(entry *Entry) string {
+	if entry.Broken {
+		return "?"
+	}
+	if l.opts.NumericIDs || entry.Group == "" {
+		return strconv.FormatUint(uint64(entry.Gid), 10)
+	}
+	return entry.Group
+}
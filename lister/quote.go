@@ -0,0 +1,369 @@
+package lister
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// QuotingStyle selects how names are quoted and how characters that
+// cannot be shown as they are get escaped
+type QuotingStyle int
+
+const (
+	QuotingLiteral           QuotingStyle = iota // Names as they are (-N), the default
+	QuotingLocale                                // ‘name’ with C escapes
+	QuotingShell                                 // 'name' where the shell needs it
+	QuotingShellAlways                           // Always 'name'
+	QuotingShellEscape                           // Like shell, with $'\n' for unprintable characters
+	QuotingShellEscapeAlways                     // Like shell-always, with $'\n' for unprintable characters
+	QuotingC                                     // "name" with C escapes (-Q)
+	QuotingEscape                                // C escapes without quotes (-b)
+	quotingCMaybe                                // "name" with C escapes where needed
+)
+
+// Return name quoted in the given style, as GNU quotearg does it. The
+// characters in quoteThese are quoted on top of those the style quotes.
+func quote(name string, style QuotingStyle, quoteThese string) string {
+	return quoteArg(name, style, false, quoteThese)
+}
+
+// Quote arg in style. elide drops the outer quotes when nothing needs
+// them; the shell and c-maybe styles always set it.
+func quoteArg(arg string, style QuotingStyle, elide bool, quoteThese string) string {
+	return quotePass(arg, style, elide, quoteThese, false)
+}
+
+// Quote arg like quoteArg. escapeOpen starts out as if a $'...' were
+// left open, which happens in the second pass GNU quotearg makes over
+// names with an apostrophe.
+func quotePass(arg string, style QuotingStyle, elide bool, quoteThese string, escapeOpen bool) string {
+	var b strings.Builder
+	backslashEscapes := false
+	shellQuotes := false // Single quotes, with $'...' for escapes
+	closingQuote := ""
+	// The style used when quotes turn out to be needed after all
+	outer := style
+	switch style {
+	case quotingCMaybe, QuotingC:
+		outer = QuotingC
+		if style == quotingCMaybe {
+			elide = true
+		}
+		if !elide {
+			b.WriteByte('"')
+		}
+		backslashEscapes = true
+		closingQuote = `"`
+	case QuotingEscape:
+		backslashEscapes = true
+		elide = false
+	case QuotingLocale:
+		if !elide {
+			b.WriteString("‘")
+		}
+		backslashEscapes = true
+		closingQuote = "’"
+	case QuotingShell, QuotingShellAlways, QuotingShellEscape, QuotingShellEscapeAlways:
+		backslashEscapes = style == QuotingShellEscape || style == QuotingShellEscapeAlways
+		outer = QuotingShellAlways
+		if backslashEscapes {
+			outer = QuotingShellEscapeAlways
+		}
+		if style == QuotingShell || style == QuotingShellEscape {
+			elide = true
+		}
+		shellQuotes = true
+		if !elide {
+			b.WriteByte('\'')
+		}
+		closingQuote = "'"
+	default:
+		elide = false
+	}
+
+	encounteredSingleQuote := false
+	allCompat := true // Every character means the same in C and shell double quotes
+	pendingShellEscapeEnd := escapeOpen
+	escaping := false
+	// Start a backslash escape, switching to $'...' in the shell styles.
+	// It returns false when the escape means the outer quotes are needed.
+	startEscape := func() bool {
+		if elide {
+			return false
+		}
+		escaping = true
+		if shellQuotes && !pendingShellEscapeEnd {
+			b.WriteString("'$'")
+			pendingShellEscapeEnd = true
+		}
+		b.WriteByte('\\')
+		return true
+	}
+	// Go back to plain single quotes after $'...'
+	endEscape := func() {
+		if pendingShellEscapeEnd && !escaping {
+			b.WriteString("''")
+			pendingShellEscapeEnd = false
+		}
+	}
+	forceOuter := func() string {
+		return quoteArg(arg, outer, false, "")
+	}
+
+	for i := 0; i < len(arg); i++ {
+		c := arg[i]
+		escaping = false
+		isClosingQuote := false
+		compat := false
+		storeEscape := false
+		checkQuoteThese := true
+
+		if backslashEscapes && !shellQuotes && closingQuote != "" && strings.HasPrefix(arg[i:], closingQuote) {
+			if elide {
+				return forceOuter()
+			}
+			isClosingQuote = true
+		}
+
+		esc := byte(0)
+		switch c {
+		case '\a':
+			esc = 'a'
+		case '\b':
+			esc = 'b'
+		case '\f':
+			esc = 'f'
+		case '\n':
+			esc = 'n'
+		case '\r':
+			esc = 'r'
+		case '\t':
+			esc = 't'
+		case '\v':
+			esc = 'v'
+		case '\\':
+			esc = '\\'
+		}
+		switch {
+		case c == '\\' && shellQuotes:
+			// Backslashes are never special within single quotes
+			if elide {
+				return forceOuter()
+			}
+			checkQuoteThese = false
+		case c == '\\' && backslashEscapes && elide && closingQuote != "":
+			// Nothing else needs quoting yet, so leave the backslash alone
+			checkQuoteThese = false
+		case esc != 0:
+			if shellQuotes && elide && c != '\a' && c != '\b' && c != '\f' && c != '\v' {
+				return forceOuter()
+			}
+			if backslashEscapes {
+				c = esc
+				storeEscape = true
+			}
+		case c == '?':
+			if shellQuotes && elide {
+				return forceOuter()
+			}
+		case strings.IndexByte("!\"$&()*;<=>[^`| #~{}", c) >= 0:
+			switch {
+			case (c == '{' || c == '}') && len(arg) != 1:
+				// Braces are only special on their own
+			case (c == '#' || c == '~') && i != 0:
+				// Comments and home directories only start words
+			default:
+				compat = c == ' ' || c == '#' || c == '~' || c == '{' || c == '}'
+				if shellQuotes && elide {
+					return forceOuter()
+				}
+			}
+		case c == '\'':
+			encounteredSingleQuote = true
+			compat = true
+			if shellQuotes {
+				if elide {
+					return forceOuter()
+				}
+				b.WriteString(`'\'`)
+				pendingShellEscapeEnd = false
+			}
+		case isPlainByte(c):
+			// These never cause problems, whatever the style
+			compat = true
+		default:
+			// A multibyte character, or a byte that cannot be printed
+			size := 1
+			printable := c >= 0x20 && c < 0x7f
+			if c >= utf8.RuneSelf {
+				if r, n := utf8.DecodeRuneInString(arg[i:]); r != utf8.RuneError || n > 1 {
+					size, printable = n, isPrintable(r)
+				}
+			}
+			compat = printable
+			if size > 1 || (backslashEscapes && !printable) {
+				// Copy the sequence, escaping every byte in octal when it
+				// cannot be printed
+				last := i + size - 1
+				for {
+					if backslashEscapes && !printable {
+						if !startEscape() {
+							return forceOuter()
+						}
+						b.WriteByte('0' + c>>6)
+						b.WriteByte('0' + (c>>3)&7)
+						c = '0' + c&7
+					} else if isClosingQuote {
+						b.WriteByte('\\')
+						isClosingQuote = false
+					}
+					if i >= last {
+						break
+					}
+					endEscape()
+					b.WriteByte(c)
+					i++
+					c = arg[i]
+				}
+				checkQuoteThese = false
+			}
+		}
+
+		if checkQuoteThese && !storeEscape {
+			extra := ((backslashEscapes && !shellQuotes) || elide) && strings.IndexByte(quoteThese, c) >= 0
+			storeEscape = extra || isClosingQuote
+		}
+		if storeEscape && !startEscape() {
+			return forceOuter()
+		}
+		endEscape()
+		b.WriteByte(c)
+		if !compat {
+			allCompat = false
+		}
+	}
+
+	if b.Len() == 0 && shellQuotes && elide {
+		return forceOuter()
+	}
+	// Names with an apostrophe read better in double quotes, when those
+	// mean the same to C and to the shell
+	if shellQuotes && !elide && encounteredSingleQuote && allCompat {
+		return quoteArg(arg, QuotingC, false, quoteThese)
+	}
+	// Otherwise GNU writes the name a second time, and a $'...' still open
+	// at the end of the first pass opens the second one with ''
+	if shellQuotes && !elide && encounteredSingleQuote && pendingShellEscapeEnd && !escapeOpen {
+		return quotePass(arg, style, elide, quoteThese, true)
+	}
+	if !elide {
+		b.WriteString(closingQuote)
+	}
+	return b.String()
+}
+
+// Report whether c is a character no quoting style ever has to quote
+func isPlainByte(c byte) bool {
+	return (c >= '0' && c <= ':') || (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || strings.IndexByte("%+,-./]_", c) >= 0
+}
+
+// Report whether r can be written to a terminal as it is. Format
+// characters such as the zero width joiner are fine, except the bidi
+// controls that could make a name display as a different one.
+func isPrintable(r rune) bool {
+	if (r >= 0x202A && r <= 0x202E) || (r >= 0x2066 && r <= 0x2069) {
+		return false
+	}
+	return unicode.IsGraphic(r) || unicode.Is(unicode.Cf, r)
+}
+
+// Replace every character that cannot be printed with '?', as -q does
+func hideControl(name string) string {
+	var b strings.Builder
+	for i := 0; i < len(name); {
+		r, size := utf8.DecodeRuneInString(name[i:])
+		if (r == utf8.RuneError && size == 1) || !isPrintable(r) {
+			b.WriteByte('?')
+		} else {
+			b.WriteString(name[i : i+size])
+		}
+		i += size
+	}
+	return b.String()
+}
+
+// Characters quoted in file names on top of those the style quotes
+func (l *listing) filenameQuoting() string {
+	quoteThese := ""
+	if l.opts.Quoting == QuotingEscape {
+		quoteThese += " "
+	}
+	// Indicator characters must not look like part of the name. As in GNU
+	// ls, only --file-type quotes '*'.
+	switch l.opts.Indicator {
+	case IndicatorFileType:
+		quoteThese += "*=>@|"
+	case IndicatorClassify:
+		quoteThese += "=>@|"
+	}
+	return quoteThese
+}
+
+// Quote name for the listing with the selected style, quoting the
+// characters in quoteThese on top of the usual ones. quoted reports
+// whether the style changed name, which decides the padding of unquoted
+// names next to quoted ones.
+func (l *listing) showName(name, quoteThese string) (text string, quoted bool) {
+	text = quote(name, l.opts.Quoting, quoteThese)
+	quoted = text != name
+	if l.opts.HideControl {
+		switch l.opts.Quoting {
+		case QuotingLiteral, QuotingShell, QuotingShellAlways:
+			text = hideControl(text)
+		}
+	}
+	return text, quoted
+}
+
+// Report whether names in a block holding a quoted one get a leading
+// space, so that the quotes stand out and names stay aligned
+func (l *listing) alignsQuotes() bool {
+	switch l.opts.Quoting {
+	case QuotingShell, QuotingShellEscape, quotingCMaybe:
+	default:
+		return false
+	}
+	return l.opts.Format == FormatLong || ((l.opts.Format == FormatColumns || l.opts.Format == FormatAcross) && l.opts.Width > 0)
+}
+
+// Report whether any of entries is quoted when printed
+func (l *listing) someQuoted(entries []*Entry) bool {
+	if !l.alignsQuotes() {
+		return false
+	}
+	for _, entry := range entries {
+		if _, quoted := l.showName(entry.Name, l.filenameQuoting()); quoted {
+			return true
+		}
+	}
+	return false
+}
+
+// Render the name of entry as it is printed: quoted, colored, and padded
+// when other names of its block are quoted
+func (l *listing) displayName(entry *Entry) string {
+	text, _, _ := l.displayNameSpan(entry)
+	return text
+}
+
+// Render the name of entry like displayName, also returning where the
+// quoted name starts and ends in the text, without padding and colors
+func (l *listing) displayNameSpan(entry *Entry) (text string, start, end int) {
+	name, quoted := l.showName(entry.Name, l.filenameQuoting())
+	before, after := l.nameColor(entry)
+	if l.padNames && !quoted {
+		before = " " + before
+	}
+	return before + name + after, len(before), len(before) + len(name)
+}
@@ -0,0 +1,250 @@
+package lister
+
+import "testing"
+
+// Expected names were taken from GNU ls 9.1 in a UTF-8 locale, except
+// for the bidi controls, which GNU prints as they are. The space -b
+// escapes comes from filenameQuoting, checked in TestShowName.
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		name string
+		want map[QuotingStyle]string
+	}{
+		{"plain", map[QuotingStyle]string{
+			QuotingLiteral:           "plain",
+			QuotingLocale:            "‘plain’",
+			QuotingShell:             "plain",
+			QuotingShellAlways:       "'plain'",
+			QuotingShellEscape:       "plain",
+			QuotingShellEscapeAlways: "'plain'",
+			QuotingC:                 `"plain"`,
+			QuotingEscape:            "plain",
+			quotingCMaybe:            "plain",
+		}},
+		{"a b", map[QuotingStyle]string{
+			QuotingLiteral:           "a b",
+			QuotingLocale:            "‘a b’",
+			QuotingShell:             "'a b'",
+			QuotingShellAlways:       "'a b'",
+			QuotingShellEscape:       "'a b'",
+			QuotingShellEscapeAlways: "'a b'",
+			QuotingC:                 `"a b"`,
+			QuotingEscape:            "a b",
+			quotingCMaybe:            "a b",
+		}},
+		{"it's", map[QuotingStyle]string{
+			QuotingLiteral:           "it's",
+			QuotingLocale:            "‘it's’",
+			QuotingShell:             `"it's"`,
+			QuotingShellAlways:       `"it's"`,
+			QuotingShellEscape:       `"it's"`,
+			QuotingShellEscapeAlways: `"it's"`,
+			QuotingC:                 `"it's"`,
+			QuotingEscape:            "it's",
+			quotingCMaybe:            "it's",
+		}},
+		{"esc\x1b", map[QuotingStyle]string{
+			QuotingLiteral:           "esc\x1b",
+			QuotingLocale:            `‘esc\033’`,
+			QuotingShell:             "esc\x1b",
+			QuotingShellAlways:       "'esc\x1b'",
+			QuotingShellEscape:       `'esc'$'\033'`,
+			QuotingShellEscapeAlways: `'esc'$'\033'`,
+			QuotingC:                 `"esc\033"`,
+			QuotingEscape:            `esc\033`,
+			quotingCMaybe:            `"esc\033"`,
+		}},
+		{"nl\n", map[QuotingStyle]string{
+			QuotingLiteral:           "nl\n",
+			QuotingLocale:            `‘nl\n’`,
+			QuotingShell:             "'nl\n'",
+			QuotingShellAlways:       "'nl\n'",
+			QuotingShellEscape:       `'nl'$'\n'`,
+			QuotingShellEscapeAlways: `'nl'$'\n'`,
+			QuotingC:                 `"nl\n"`,
+			QuotingEscape:            `nl\n`,
+			quotingCMaybe:            `"nl\n"`,
+		}},
+		{"bad\xff", map[QuotingStyle]string{
+			QuotingLiteral:           "bad\xff",
+			QuotingLocale:            `‘bad\377’`,
+			QuotingShell:             "bad\xff",
+			QuotingShellAlways:       "'bad\xff'",
+			QuotingShellEscape:       `'bad'$'\377'`,
+			QuotingShellEscapeAlways: `'bad'$'\377'`,
+			QuotingC:                 `"bad\377"`,
+			QuotingEscape:            `bad\377`,
+			quotingCMaybe:            `"bad\377"`,
+		}},
+		{"#hash", map[QuotingStyle]string{
+			QuotingLiteral:           "#hash",
+			QuotingLocale:            "‘#hash’",
+			QuotingShell:             "'#hash'",
+			QuotingShellAlways:       "'#hash'",
+			QuotingShellEscape:       "'#hash'",
+			QuotingShellEscapeAlways: "'#hash'",
+			QuotingC:                 `"#hash"`,
+			QuotingEscape:            "#hash",
+			quotingCMaybe:            "#hash",
+		}},
+		{"a#b", map[QuotingStyle]string{
+			QuotingLiteral:           "a#b",
+			QuotingLocale:            "‘a#b’",
+			QuotingShell:             "a#b",
+			QuotingShellAlways:       "'a#b'",
+			QuotingShellEscape:       "a#b",
+			QuotingShellEscapeAlways: "'a#b'",
+			QuotingC:                 `"a#b"`,
+			QuotingEscape:            "a#b",
+			quotingCMaybe:            "a#b",
+		}},
+		{"~tilde", map[QuotingStyle]string{
+			QuotingLiteral:           "~tilde",
+			QuotingLocale:            "‘~tilde’",
+			QuotingShell:             "'~tilde'",
+			QuotingShellAlways:       "'~tilde'",
+			QuotingShellEscape:       "'~tilde'",
+			QuotingShellEscapeAlways: "'~tilde'",
+			QuotingC:                 `"~tilde"`,
+			QuotingEscape:            "~tilde",
+			quotingCMaybe:            "~tilde",
+		}},
+		{"a~", map[QuotingStyle]string{
+			QuotingLiteral:           "a~",
+			QuotingLocale:            "‘a~’",
+			QuotingShell:             "a~",
+			QuotingShellAlways:       "'a~'",
+			QuotingShellEscape:       "a~",
+			QuotingShellEscapeAlways: "'a~'",
+			QuotingC:                 `"a~"`,
+			QuotingEscape:            "a~",
+			quotingCMaybe:            "a~",
+		}},
+		{"{", map[QuotingStyle]string{
+			QuotingLiteral:           "{",
+			QuotingLocale:            "‘{’",
+			QuotingShell:             "'{'",
+			QuotingShellAlways:       "'{'",
+			QuotingShellEscape:       "'{'",
+			QuotingShellEscapeAlways: "'{'",
+			QuotingC:                 `"{"`,
+			QuotingEscape:            "{",
+			quotingCMaybe:            "{",
+		}},
+		{"{a}", map[QuotingStyle]string{
+			QuotingLiteral:           "{a}",
+			QuotingLocale:            "‘{a}’",
+			QuotingShell:             "{a}",
+			QuotingShellAlways:       "'{a}'",
+			QuotingShellEscape:       "{a}",
+			QuotingShellEscapeAlways: "'{a}'",
+			QuotingC:                 `"{a}"`,
+			QuotingEscape:            "{a}",
+			quotingCMaybe:            "{a}",
+		}},
+		// A right-to-left override could make the name read as another one
+		{"a\u202eb", map[QuotingStyle]string{
+			QuotingLiteral:           "a\u202eb",
+			QuotingLocale:            `‘a\342\200\256b’`,
+			QuotingShell:             "a\u202eb",
+			QuotingShellAlways:       "'a\u202eb'",
+			QuotingShellEscape:       `'a'$'\342\200\256''b'`,
+			QuotingShellEscapeAlways: `'a'$'\342\200\256''b'`,
+			QuotingC:                 `"a\342\200\256b"`,
+			QuotingEscape:            `a\342\200\256b`,
+			quotingCMaybe:            `"a\342\200\256b"`,
+		}},
+		{`back\slash`, map[QuotingStyle]string{
+			QuotingLiteral:           `back\slash`,
+			QuotingLocale:            `‘back\\slash’`,
+			QuotingShell:             `'back\slash'`,
+			QuotingShellAlways:       `'back\slash'`,
+			QuotingShellEscape:       `'back\slash'`,
+			QuotingShellEscapeAlways: `'back\slash'`,
+			QuotingC:                 `"back\\slash"`,
+			QuotingEscape:            `back\\slash`,
+			quotingCMaybe:            `back\slash`,
+		}},
+		{`q"uote`, map[QuotingStyle]string{
+			QuotingLiteral:           `q"uote`,
+			QuotingLocale:            `‘q"uote’`,
+			QuotingShell:             `'q"uote'`,
+			QuotingShellAlways:       `'q"uote'`,
+			QuotingShellEscape:       `'q"uote'`,
+			QuotingShellEscapeAlways: `'q"uote'`,
+			QuotingC:                 `"q\"uote"`,
+			QuotingEscape:            `q"uote`,
+			quotingCMaybe:            `"q\"uote"`,
+		}},
+		{"héllo", map[QuotingStyle]string{
+			QuotingLiteral:           "héllo",
+			QuotingLocale:            "‘héllo’",
+			QuotingShell:             "héllo",
+			QuotingShellAlways:       "'héllo'",
+			QuotingShellEscape:       "héllo",
+			QuotingShellEscapeAlways: "'héllo'",
+			QuotingC:                 `"héllo"`,
+			QuotingEscape:            "héllo",
+			quotingCMaybe:            "héllo",
+		}},
+		{"it's\n", map[QuotingStyle]string{
+			QuotingLiteral:           "it's\n",
+			QuotingLocale:            `‘it's\n’`,
+			QuotingShell:             "'it'\\''s\n'",
+			QuotingShellAlways:       "'it'\\''s\n'",
+			QuotingShellEscape:       `'''it'\''s'$'\n'`,
+			QuotingShellEscapeAlways: `'''it'\''s'$'\n'`,
+			QuotingC:                 `"it's\n"`,
+			QuotingEscape:            `it's\n`,
+			quotingCMaybe:            `"it's\n"`,
+		}},
+	}
+	for _, test := range tests {
+		for style, want := range test.want {
+			if got := quote(test.name, style, ""); got != want {
+				t.Errorf("quote(%q, %s) = %q, want %q", test.name, quotingStyleNames[style], got, want)
+			}
+		}
+	}
+}
+
+func TestShowName(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		// Indicator characters are quoted with -F and --file-type
+		{"a=b", Options{Quoting: QuotingEscape, Indicator: IndicatorClassify}, `a\=b`},
+		{"a@b", Options{Quoting: QuotingEscape, Indicator: IndicatorClassify}, `a\@b`},
+		{"a|b", Options{Quoting: QuotingEscape, Indicator: IndicatorClassify}, `a\|b`},
+		{"a>b", Options{Quoting: QuotingEscape, Indicator: IndicatorClassify}, `a\>b`},
+		{"a@b", Options{Quoting: QuotingEscape}, "a@b"},
+		{"a@b", Options{Quoting: QuotingShellEscape, Indicator: IndicatorClassify}, "'a@b'"},
+		{"a@b", Options{Quoting: QuotingShellEscape}, "a@b"},
+		{"a=b", Options{Quoting: quotingCMaybe, Indicator: IndicatorClassify}, `"a=b"`},
+		// Only --file-type quotes '*'
+		{"a*b", Options{Quoting: QuotingEscape, Indicator: IndicatorClassify}, "a*b"},
+		{"a*b", Options{Quoting: QuotingEscape, Indicator: IndicatorFileType}, `a\*b`},
+		{"a*b", Options{Quoting: quotingCMaybe, Indicator: IndicatorFileType}, `"a*b"`},
+		// -b escapes spaces too
+		{"a b", Options{Quoting: QuotingEscape}, `a\ b`},
+		// -q hides what the literal and shell styles leave unescaped
+		{"esc\x1b", Options{Quoting: QuotingLiteral, HideControl: true}, "esc?"},
+		{"nl\n", Options{Quoting: QuotingLiteral, HideControl: true}, "nl?"},
+		{"esc\x1b", Options{Quoting: QuotingShell, HideControl: true}, "esc?"},
+		{"nl\n", Options{Quoting: QuotingShell, HideControl: true}, "'nl?'"},
+		{"nl\n", Options{Quoting: QuotingShellAlways, HideControl: true}, "'nl?'"},
+		{"a\u202eb", Options{Quoting: QuotingLiteral, HideControl: true}, "a?b"},
+		{"bad\xff", Options{Quoting: QuotingLiteral, HideControl: true}, "bad?"},
+		// The escaping styles have nothing left to hide
+		{"nl\n", Options{Quoting: QuotingShellEscape, HideControl: true}, `'nl'$'\n'`},
+		{"nl\n", Options{Quoting: QuotingC, HideControl: true}, `"nl\n"`},
+	}
+	for i, test := range tests {
+		l := &listing{opts: test.opts}
+		if got, _ := l.showName(test.name, l.filenameQuoting()); got != test.want {
+			t.Errorf("case %d: showName(%q) = %q, want %q", i, test.name, got, test.want)
+		}
+	}
+}
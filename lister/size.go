@@ -0,0 +1,160 @@
+package lister
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// BlockSize describes how byte counts are printed in the size column and
+// on the "total" line. The zero value keeps the GNU defaults: sizes in
+// bytes and totals in 1024-byte blocks.
+type BlockSize struct {
+	Unit      int64  // Bytes per printed unit; 0 selects the default
+	Suffix    string // Appended to numbers printed in Unit, such as "K" or "MiB"
+	Human     bool   // Scale every number to the largest fitting unit instead (-h)
+	SI        bool   // Scale by powers of 1000 rather than 1024 (--si)
+	Thousands bool   // Group digits in threes with ','
+}
+
+// Unit letters in increasing powers
+const unitLetters = "KMGTPEZY"
+
+// ParseBlockSize parses a --block-size argument: "human-readable", "si",
+// or an optional "'" (thousands separators) followed by an optional number
+// and an optional unit such as K, M, KiB or MB. Units with "B" are powers
+// of 1000, the others powers of 1024. A unit without a leading number is
+// printed after every size.
+func ParseBlockSize(spec string) (BlockSize, error) {
+	switch spec {
+	case "human-readable":
+		return BlockSize{Human: true}, nil
+	case "si":
+		return BlockSize{Human: true, SI: true}, nil
+	}
+	bs := BlockSize{}
+	if strings.HasPrefix(spec, "'") {
+		bs.Thousands = true
+		spec = spec[1:]
+	}
+	// There must be a number or a unit after the optional "'"
+	if spec == "" {
+		return BlockSize{}, errors.New("invalid block size")
+	}
+	// Split the number from the unit
+	digits := 0
+	for digits < len(spec) && isDigit(spec[digits]) {
+		digits++
+	}
+	count := int64(1)
+	if digits > 0 {
+		var err error
+		count, err = strconv.ParseInt(spec[:digits], 10, 64)
+		if err != nil || count == 0 {
+			return BlockSize{}, errors.New("invalid block size")
+		}
+	}
+	unit := spec[digits:]
+	multiplier := int64(1)
+	if unit != "" {
+		power := strings.IndexByte(unitLetters, byte(strings.ToUpper(unit[:1])[0])) + 1
+		base := int64(0)
+		switch unit[1:] {
+		case "", "iB":
+			base = 1024
+		case "B":
+			base = 1000
+		}
+		if power == 0 || base == 0 {
+			return BlockSize{}, errors.New("invalid block size")
+		}
+		for i := 0; i < power; i++ {
+			if multiplier > math.MaxInt64/base {
+				return BlockSize{}, errors.New("block size too large")
+			}
+			multiplier *= base
+		}
+		// Only a bare unit is printed after the numbers, SI kilo in lower case
+		if digits == 0 {
+			bs.Suffix = strings.ToUpper(unit[:1]) + unit[1:]
+			if bs.Suffix == "KB" {
+				bs.Suffix = "kB"
+			}
+		}
+	}
+	if count > math.MaxInt64/multiplier {
+		return BlockSize{}, errors.New("block size too large")
+	}
+	bs.Unit = count * multiplier
+	return bs, nil
+}
+
+// Format a byte count. defaultUnit applies when no unit was chosen.
+// Like GNU ls, every scaled number is rounded up.
+func (bs BlockSize) format(bytes int64, defaultUnit int64) string {
+	if bs.Human {
+		return bs.formatHuman(bytes)
+	}
+	unit := bs.Unit
+	if unit == 0 {
+		unit = defaultUnit
+	}
+	// Divide rounding up
+	value := bytes / unit
+	if bytes%unit != 0 {
+		value++
+	}
+	return bs.group(strconv.FormatInt(value, 10)) + bs.Suffix
+}
+
+// Format a byte count with the largest unit that keeps it at least 1,
+// with one decimal below 10 as in "1.5K", "12M" or "912"
+func (bs BlockSize) formatHuman(bytes int64) string {
+	base := 1024.0
+	if bs.SI {
+		base = 1000
+	}
+	value := float64(bytes)
+	power := 0
+	for value >= base && power < len(unitLetters) {
+		value /= base
+		power++
+	}
+	if power == 0 {
+		return bs.group(strconv.FormatInt(bytes, 10))
+	}
+	number := ""
+	if value < 10 && math.Ceil(value*10) < 100 {
+		number = strconv.FormatFloat(math.Ceil(value*10)/10, 'f', 1, 64)
+	} else {
+		value = math.Ceil(value)
+		// Rounding up may carry over into the next unit
+		if value >= base && power < len(unitLetters) {
+			power++
+			number = "1.0"
+		} else {
+			number = bs.group(strconv.FormatFloat(value, 'f', 0, 64))
+		}
+	}
+	letter := unitLetters[power-1 : power]
+	if bs.SI && letter == "K" {
+		letter = "k"
+	}
+	return number + letter
+}
+
+// Insert thousands separators into a string of digits when requested
+func (bs BlockSize) group(digits string) string {
+	if !bs.Thousands || len(digits) <= 3 {
+		return digits
+	}
+	grouped := digits[:len(digits)%3]
+	for i := len(digits) % 3; i < len(digits); i += 3 {
+		if grouped != "" {
+			grouped += ","
+		}
+		grouped += digits[i : i+3]
+	}
+	return grouped
+}
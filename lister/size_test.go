@@ -0,0 +1,117 @@
+package lister
+
+import "testing"
+
+func TestParseBlockSize(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    BlockSize
+		wantErr string
+	}{
+		{spec: "human-readable", want: BlockSize{Human: true}},
+		{spec: "si", want: BlockSize{Human: true, SI: true}},
+		{spec: "1", want: BlockSize{Unit: 1}},
+		{spec: "512", want: BlockSize{Unit: 512}},
+		// A number and a unit scale without a suffix
+		{spec: "1K", want: BlockSize{Unit: 1024}},
+		{spec: "1kB", want: BlockSize{Unit: 1000}},
+		{spec: "2MiB", want: BlockSize{Unit: 2 << 20}},
+		{spec: "7E", want: BlockSize{Unit: 7 << 60}},
+		// A bare unit is printed after the numbers, kilo in SI in lower case
+		{spec: "K", want: BlockSize{Unit: 1024, Suffix: "K"}},
+		{spec: "k", want: BlockSize{Unit: 1024, Suffix: "K"}},
+		{spec: "KiB", want: BlockSize{Unit: 1024, Suffix: "KiB"}},
+		{spec: "kiB", want: BlockSize{Unit: 1024, Suffix: "KiB"}},
+		{spec: "kB", want: BlockSize{Unit: 1000, Suffix: "kB"}},
+		{spec: "KB", want: BlockSize{Unit: 1000, Suffix: "kB"}},
+		{spec: "MB", want: BlockSize{Unit: 1000000, Suffix: "MB"}},
+		// A leading "'" groups digits, but needs something after it
+		{spec: "'1", want: BlockSize{Unit: 1, Thousands: true}},
+		{spec: "'K", want: BlockSize{Unit: 1024, Suffix: "K", Thousands: true}},
+		{spec: "'", wantErr: "invalid block size"},
+		{spec: "''", wantErr: "invalid block size"},
+		{spec: "", wantErr: "invalid block size"},
+		{spec: "0", wantErr: "invalid block size"},
+		{spec: "x", wantErr: "invalid block size"},
+		{spec: "1Q", wantErr: "invalid block size"},
+		{spec: "1KB2", wantErr: "invalid block size"},
+		{spec: "-1", wantErr: "invalid block size"},
+		// Overflow of the number, of the unit, and of the two together
+		{spec: "99999999999999999999", wantErr: "invalid block size"},
+		{spec: "1Y", wantErr: "block size too large"},
+		{spec: "16E", wantErr: "block size too large"},
+		{spec: "10000000000ZB", wantErr: "block size too large"},
+		{spec: "10000000000000GiB", wantErr: "block size too large"},
+	}
+	for _, test := range tests {
+		got, err := ParseBlockSize(test.spec)
+		if test.wantErr != "" {
+			if err == nil || err.Error() != test.wantErr {
+				t.Errorf("ParseBlockSize(%q) = %+v, %v, want error %q", test.spec, got, err, test.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != test.want {
+			t.Errorf("ParseBlockSize(%q) = %+v, %v, want %+v", test.spec, got, err, test.want)
+		}
+	}
+}
+
+// Sizes as GNU ls 9.1 prints them with -l and the given block size, in a
+// locale grouping thousands with ','
+func TestFormatSize(t *testing.T) {
+	human := BlockSize{Human: true}
+	si := BlockSize{Human: true, SI: true}
+	tests := []struct {
+		bs    BlockSize
+		bytes int64
+		want  string
+	}{
+		{BlockSize{}, 1048576, "1048576"},
+		{BlockSize{Unit: 1024, Suffix: "KiB"}, 1048576, "1024KiB"},
+		{BlockSize{Unit: 1000, Suffix: "kB"}, 1048576, "1049kB"},
+		{BlockSize{Unit: 1000000, Suffix: "MB"}, 1048576, "2MB"},
+		{BlockSize{Unit: 1000}, 1048576, "1049"},
+		{BlockSize{Unit: 1024}, 0, "0"},
+		{BlockSize{Unit: 1, Thousands: true}, 1234567, "1,234,567"},
+		{BlockSize{Unit: 1, Thousands: true}, 123456, "123,456"},
+		{BlockSize{Unit: 1, Thousands: true}, 999, "999"},
+		{BlockSize{Unit: 1024, Suffix: "K", Thousands: true}, 1 << 30, "1,048,576K"},
+		// Human sizes round up, keeping one decimal below 10
+		{human, 0, "0"},
+		{human, 1023, "1023"},
+		{human, 1024, "1.0K"},
+		{human, 1025, "1.1K"},
+		{human, 9950, "9.8K"},
+		{human, 10150, "10K"},
+		{human, 10239, "10K"},
+		{human, 999999, "977K"},
+		{human, 102400000, "98M"},
+		// Rounding up to the base carries over into the next unit
+		{human, 1048575, "1.0M"},
+		{human, 1048576, "1.0M"},
+		{si, 999, "999"},
+		{si, 1023, "1.1k"},
+		{si, 1000000, "1.0M"},
+		{si, 999999, "1.0M"},
+		{si, 9950, "10k"},
+		{si, 10150, "11k"},
+		{si, 102400000, "103M"},
+		{BlockSize{Human: true, Thousands: true}, 1023, "1,023"},
+	}
+	for _, test := range tests {
+		if got := test.bs.format(test.bytes, 1); got != test.want {
+			t.Errorf("%+v: format(%d) = %q, want %q", test.bs, test.bytes, got, test.want)
+		}
+	}
+}
+
+// The "total" line and -s count in 1024-byte blocks unless told otherwise
+func TestFormatDefaultUnit(t *testing.T) {
+	if got := (BlockSize{}).format(4097, 1024); got != "5" {
+		t.Errorf("got %q, want %q", got, "5")
+	}
+	if got := (BlockSize{Unit: 512}).format(4097, 1024); got != "9" {
+		t.Errorf("got %q, want %q", got, "9")
+	}
+}
@@ -0,0 +1,226 @@
+package lister
+
+import (
+	"sort"
+	"strings"
+)
+
+// SortKey selects the order in which entries are listed
+type SortKey int
+
+const (
+	SortName      SortKey = iota // By name, the default
+	SortNone                     // Directory order, no sorting at all (-U)
+	SortTime                     // By the timestamp selected by Options.Time, newest first (-t)
+	SortSize                     // By size, largest first (-S)
+	SortExtension                // By extension, then name (-X)
+	SortVersion                  // Natural order of version numbers within names (-v)
+)
+
+// Compare two entries under one sort key, returning a negative number when
+// a comes first, a positive one when b does and 0 when the key does not
+// tell them apart
+type compareFunc func(a, b *Entry) int
+
+// Primary comparison of every sort key. Ties are always broken by name,
+// so a key only has to decide what makes it different.
+var sortKeys = map[SortKey]compareFunc{
+	SortName:      func(a, b *Entry) int { return 0 },
+	SortTime:      compareTime,
+	SortSize:      compareSize,
+	SortExtension: compareExtension,
+	SortVersion:   compareVersion,
+}
+
+// Sort entries by the selected key, falling back to the name for ties.
+// -r reverses the whole order; directory order (-U) is never changed.
+// With --group-directories-first, directories and links to them come
+// before everything else whatever the key and -r.
+func (l *listing) sortEntries(entries []*Entry) {
+	compare, ok := sortKeys[l.opts.Sort]
+	if !ok {
+		return
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if l.opts.GroupDirectoriesFirst {
+			if iDir, jDir := entries[i].isLinkedDir(), entries[j].isLinkedDir(); iDir != jDir {
+				return iDir
+			}
+		}
+		res := compare(entries[i], entries[j])
+		if res == 0 {
+			res = strings.Compare(entries[i].Name, entries[j].Name)
+		}
+		if l.opts.Reverse {
+			return res > 0
+		}
+		return res < 0
+	})
+}
+
+// Newest first by the selected timestamp
+func compareTime(a, b *Entry) int {
+	switch {
+	case a.Time.After(b.Time):
+		return -1
+	case a.Time.Before(b.Time):
+		return 1
+	}
+	return 0
+}
+
+// Largest first
+func compareSize(a, b *Entry) int {
+	switch {
+	case a.Size > b.Size:
+		return -1
+	case a.Size < b.Size:
+		return 1
+	}
+	return 0
+}
+
+// Names without an extension first, then alphabetically by extension
+func compareExtension(a, b *Entry) int {
+	return strings.Compare(extension(a.Name), extension(b.Name))
+}
+
+// Return everything from the last '.' of name, or "" when it has none
+func extension(name string) string {
+	if i := strings.LastIndexByte(name, '.'); i >= 0 {
+		return name[i:]
+	}
+	return ""
+}
+
+// Order names like GNU filevercmp: . and .. first, then hidden files, then
+// the rest, comparing runs of digits by their numeric value and ignoring
+// a trailing file suffix such as ".tar.gz" unless the rest is equal
+func compareVersion(a, b *Entry) int {
+	return filevercmp(a.Name, b.Name)
+}
+
+// Compare two file names by version
+func filevercmp(a, b string) int {
+	if a == b {
+		return 0
+	}
+	// . and .. always come before everything else
+	for _, special := range []string{".", ".."} {
+		if a == special {
+			return -1
+		}
+		if b == special {
+			return 1
+		}
+	}
+	// Hidden files come before the others
+	aHidden, bHidden := a[0] == '.', b[0] == '.'
+	if aHidden != bHidden {
+		if aHidden {
+			return -1
+		}
+		return 1
+	}
+	if aHidden {
+		a, b = a[1:], b[1:]
+	}
+	// Compare without the suffixes first, then with them
+	aBase, bBase := a[:len(a)-len(fileSuffix(a))], b[:len(b)-len(fileSuffix(b))]
+	if res := verrevcmp(aBase, bBase); res != 0 {
+		return res
+	}
+	return verrevcmp(a, b)
+}
+
+// Return the longest suffix of name matching (\.[A-Za-z~][A-Za-z0-9~]*)*$.
+// A suffix covering the whole name does not count.
+func fileSuffix(name string) string {
+	start := len(name)
+	for {
+		// Walk back over the characters of one suffix component
+		j := start
+		for j > 0 && (isAlpha(name[j-1]) || isDigit(name[j-1]) || name[j-1] == '~') {
+			j--
+		}
+		// It must be a '.' followed by a letter or '~'
+		if j == 0 || j == start || name[j-1] != '.' || isDigit(name[j]) {
+			break
+		}
+		start = j - 1
+	}
+	if start == 0 {
+		return ""
+	}
+	return name[start:]
+}
+
+// Debian style version comparison: non-digit parts are compared with
+// letters before other characters and '~' before anything, digit parts
+// by their numeric value
+func verrevcmp(a, b string) int {
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		firstDiff := 0
+		// Compare the non-digit prefixes character by character
+		for (i < len(a) && !isDigit(a[i])) || (j < len(b) && !isDigit(b[j])) {
+			ac, bc := 0, 0
+			if i < len(a) {
+				ac = verOrder(a[i])
+			}
+			if j < len(b) {
+				bc = verOrder(b[j])
+			}
+			if ac != bc {
+				return ac - bc
+			}
+			i++
+			j++
+		}
+		// Skip leading zeros, then compare the digit runs numerically
+		for i < len(a) && a[i] == '0' {
+			i++
+		}
+		for j < len(b) && b[j] == '0' {
+			j++
+		}
+		for i < len(a) && isDigit(a[i]) && j < len(b) && isDigit(b[j]) {
+			if firstDiff == 0 {
+				firstDiff = int(a[i]) - int(b[j])
+			}
+			i++
+			j++
+		}
+		if i < len(a) && isDigit(a[i]) {
+			return 1
+		}
+		if j < len(b) && isDigit(b[j]) {
+			return -1
+		}
+		if firstDiff != 0 {
+			return firstDiff
+		}
+	}
+	return 0
+}
+
+// Weight of a non-digit character in verrevcmp
+func verOrder(c byte) int {
+	switch {
+	case isDigit(c):
+		return 0
+	case isAlpha(c):
+		return int(c)
+	case c == '~':
+		return -1
+	}
+	return int(c) + 256
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isAlpha(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
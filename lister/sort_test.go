@@ -0,0 +1,120 @@
+package lister
+
+import (
+	"strings"
+	"testing"
+)
+
+// Sign of a comparison result
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
+
+func TestFilevercmp(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		// . and .. come first, then hidden files
+		{".", "..", -1},
+		{".", ".hidden", -1},
+		{"..", ".hidden", -1},
+		{"..", "a", -1},
+		{".hidden", "a", -1},
+		{".z", "a", -1},
+		{".a2", ".a10", -1},
+		{".a", "a", -1},
+		// Runs of digits compare by value
+		{"a2", "a10", -1},
+		{"a10", "a9", 1},
+		{"file-1.9", "file-1.10", -1},
+		{"file-1.0", "file-1.0.1", -1},
+		{"1", "10", -1},
+		// Leading zeros do not count, leaving the name to break the tie
+		{"a1", "a01", 0},
+		{"a001", "a1", 0},
+		{"01", "1", 0},
+		{"a01", "a2", -1},
+		// Suffixes are ignored unless the rest is equal
+		{"a.tar.gz", "a1.tar.gz", -1},
+		{"a2.tar.gz", "a10.tar.gz", -1},
+		{"a1.tar.gz", "a2", -1},
+		{"a.tar", "a.tar.gz", -1},
+		{"file-1.0.tar.gz", "file-1.0.1", -1},
+		{"README", "README.md", -1},
+		{".tar.gz", "a", -1},
+		// '~' sorts before anything, even the end of the name
+		{"a~", "a", -1},
+		{"file-1.0~rc1", "file-1.0", -1},
+		{"z~9", "z9", -1},
+		{"~", "01", -1},
+		{"a.b~", "a.b", -1},
+		// Letters come before other characters
+		{"a.b", "a-b", -1},
+		{"a-b", "a_b", -1},
+		{"B", "a", -1},
+		{"a", "a", 0},
+	}
+	for _, test := range tests {
+		if got := sign(filevercmp(test.a, test.b)); got != test.want {
+			t.Errorf("filevercmp(%q, %q) = %d, want %d", test.a, test.b, got, test.want)
+		}
+		if got := sign(filevercmp(test.b, test.a)); got != -test.want {
+			t.Errorf("filevercmp(%q, %q) = %d, want %d", test.b, test.a, got, -test.want)
+		}
+	}
+}
+
+func TestVerrevcmp(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "a", -1},
+		{"~", "", -1},
+		{"1.2", "1.10", -1},
+		{"1.2a", "1.2b", -1},
+		{"1.2", "1.2a", -1},
+		{"1.2~", "1.2", -1},
+		{"1.2~1", "1.2~2", -1},
+		{"007", "7", 0},
+		{"1.02", "1.2", 0},
+		{"a", "+", -1},
+		{"18446744073709551616", "18446744073709551615", 1},
+	}
+	for _, test := range tests {
+		if got := sign(verrevcmp(test.a, test.b)); got != test.want {
+			t.Errorf("verrevcmp(%q, %q) = %d, want %d", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+// The order GNU ls 9.1 prints with -1av in the C locale
+func TestVersionOrder(t *testing.T) {
+	want := strings.Fields(". .. .a2 .a10 .hidden .tar.gz ~ ~a 01 1 10 A~ B README README.md " +
+		"a~ a a.b~ a.b a.tar a.tar.gz a001 a01 a1 a1.tar.gz a2 a2.tar.gz a10 a10.tar.gz " +
+		"a-b a_b b file-1.0~rc1 file-1.0 file-1.0.tar.gz file-1.0.1 z~9 z9")
+	entries := []*Entry{}
+	// Start from name order, as a directory read might return them
+	for _, name := range strings.Fields(". .. .a10 .a2 .hidden .tar.gz 01 1 10 A~ B README README.md " +
+		"a a-b a.b a.b~ a.tar a.tar.gz a001 a01 a1 a1.tar.gz a10 a10.tar.gz a2 a2.tar.gz " +
+		"a_b a~ b file-1.0 file-1.0.1 file-1.0.tar.gz file-1.0~rc1 z9 z~9 ~ ~a") {
+		entries = append(entries, &Entry{Name: name})
+	}
+	l := &listing{opts: Options{Sort: SortVersion}}
+	l.sortEntries(entries)
+	got := []string{}
+	for _, entry := range entries {
+		got = append(got, entry.Name)
+	}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("got  %v\nwant %v", got, want)
+	}
+}
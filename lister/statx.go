@@ -0,0 +1,73 @@
+package lister
+
+import (
+	"encoding/binary"
+	"runtime"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// statx(2) is not wrapped by the syscall package, so its number is
+// looked up per architecture. Other architectures go without birth times.
+var statxTrap = map[string]uintptr{
+	"386":     383,
+	"amd64":   332,
+	"arm":     397,
+	"arm64":   291,
+	"ppc64":   383,
+	"ppc64le": 383,
+	"riscv64": 291,
+	"s390x":   379,
+}
+
+// Flags and mask bits from <linux/stat.h> and <fcntl.h>
+const (
+	atFdcwd           = -100
+	atSymlinkNofollow = 0x100
+	statxBtime        = 0x800
+)
+
+// Offsets of the fields read from struct statx
+const (
+	statxMaskOffset  = 0
+	statxBtimeOffset = 80
+	statxBufSize     = 256
+)
+
+// Return the birth time of path without following symlinks. ok is false
+// when the kernel, the architecture or the filesystem does not record it.
+func birthTime(path string) (btime time.Time, ok bool) {
+	trap, known := statxTrap[runtime.GOARCH]
+	if !known {
+		return time.Time{}, false
+	}
+	pathPtr, err := syscall.BytePtrFromString(path)
+	if err != nil {
+		return time.Time{}, false
+	}
+	var buf [statxBufSize]byte
+	fd := atFdcwd
+	_, _, errno := syscall.Syscall6(trap, uintptr(fd), uintptr(unsafe.Pointer(pathPtr)), atSymlinkNofollow, statxBtime, uintptr(unsafe.Pointer(&buf[0])), 0)
+	if errno != 0 {
+		return time.Time{}, false
+	}
+	order := nativeEndian()
+	// The kernel clears the mask bit when the filesystem has no birth time
+	if order.Uint32(buf[statxMaskOffset:])&statxBtime == 0 {
+		return time.Time{}, false
+	}
+	// struct statx_timestamp is a 64-bit tv_sec followed by a 32-bit tv_nsec
+	sec := int64(order.Uint64(buf[statxBtimeOffset:]))
+	nsec := int64(order.Uint32(buf[statxBtimeOffset+8:]))
+	return time.Unix(sec, nsec), true
+}
+
+// Byte order of the running machine, in which the kernel fills struct statx
+func nativeEndian() binary.ByteOrder {
+	x := uint16(1)
+	if *(*byte)(unsafe.Pointer(&x)) == 1 {
+		return binary.LittleEndian
+	}
+	return binary.BigEndian
+}
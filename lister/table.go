@@ -0,0 +1,128 @@
+package lister
+
+import (
+	"encoding/csv"
+	"strconv"
+	"time"
+)
+
+// TableColumns lists the columns the CSV and TSV formats can print, as
+// named in Options.Columns
+var TableColumns = []string{
+	"path", "name", "type", "inode", "blocks", "mode", "links", "user", "group",
+	"uid", "gid", "size", "time", "atime", "mtime", "ctime", "btime", "target", "xattr",
+}
+
+// Value of every table column for an entry. Columns of a file that could
+// not be followed are left empty, apart from its path, name and type.
+var tableValues = map[string]func(l *listing, entry *Entry) string{
+	"path":   func(l *listing, entry *Entry) string { return entry.Path },
+	"name":   func(l *listing, entry *Entry) string { return entry.Name },
+	"type":   func(l *listing, entry *Entry) string { return fileType(entry.Mode) },
+	"inode":  func(l *listing, entry *Entry) string { return strconv.FormatUint(entry.Ino, 10) },
+	"blocks": func(l *listing, entry *Entry) string { return l.opts.BlockSize.format(entry.Blocks*512, 1024) },
+	"mode":   func(l *listing, entry *Entry) string { return modeString(entry.Mode) + entry.Marker },
+	"links":  func(l *listing, entry *Entry) string { return strconv.FormatUint(entry.Links, 10) },
+	"user":   func(l *listing, entry *Entry) string { return l.ownerColumn(entry) },
+	"group":  func(l *listing, entry *Entry) string { return l.groupColumn(entry) },
+	"uid":    func(l *listing, entry *Entry) string { return strconv.FormatUint(uint64(entry.Uid), 10) },
+	"gid":    func(l *listing, entry *Entry) string { return strconv.FormatUint(uint64(entry.Gid), 10) },
+	"size":   func(l *listing, entry *Entry) string { return l.opts.BlockSize.format(entry.Size, 1) },
+	"time":   func(l *listing, entry *Entry) string { return l.tableTime(entry.Time) },
+	"atime":  func(l *listing, entry *Entry) string { return l.tableTime(entry.AccessTime) },
+	"mtime":  func(l *listing, entry *Entry) string { return l.tableTime(entry.ModTime) },
+	"ctime":  func(l *listing, entry *Entry) string { return l.tableTime(entry.ChangeTime) },
+	"btime":  func(l *listing, entry *Entry) string { return l.tableTime(entry.BirthTime) },
+	"target": func(l *listing, entry *Entry) string { return entry.LinkTarget },
+	"xattr":  func(l *listing, entry *Entry) string { return entry.Marker },
+}
+
+// Columns printed when Options.Columns is empty: those of the long
+// format, with the path in place of the name so that rows read from
+// several directories can be told apart
+func defaultTableColumns(opts Options) []string {
+	columns := infoColumnsOf(opts)
+	columns = append(columns, "mode", "links")
+	if !opts.OmitOwner {
+		columns = append(columns, "user")
+	}
+	if !opts.OmitGroup {
+		columns = append(columns, "group")
+	}
+	return append(columns, "size", "time", "path", "target")
+}
+
+// Create the writer of the CSV and TSV formats. Fields are quoted as RFC
+// 4180 asks, in TSV too, so that any name survives being imported.
+func newTableWriter(l *listing) *csv.Writer {
+	table := csv.NewWriter(l.w)
+	if l.opts.Format == FormatTSV {
+		table.Comma = '\t'
+	}
+	return table
+}
+
+// Write the header row of the table, naming its columns
+func (l *listing) writeTableHeader() error {
+	l.table.Write(l.tableColumns())
+	l.table.Flush()
+	return l.table.Error()
+}
+
+// Write a table row for every entry
+func (l *listing) writeRows(entries []*Entry) error {
+	columns := l.tableColumns()
+	for _, entry := range entries {
+		row := make([]string, len(columns))
+		for i, column := range columns {
+			if entry.Broken && column != "path" && column != "name" && column != "type" {
+				continue
+			}
+			row[i] = tableValues[column](l, entry)
+		}
+		l.table.Write(row)
+	}
+	l.table.Flush()
+	return l.table.Error()
+}
+
+// Columns of the table, as chosen or by default
+func (l *listing) tableColumns() []string {
+	if len(l.opts.Columns) > 0 {
+		return l.opts.Columns
+	}
+	return defaultTableColumns(l.opts)
+}
+
+// Format a timestamp for the table: with the time style when one was
+// chosen, otherwise in RFC 3339 with nanoseconds, which spreadsheets
+// read back as dates. Timestamps the filesystem does not record are empty.
+func (l *listing) tableTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	if l.opts.TimeStyle.Explicit {
+		return l.opts.TimeStyle.format(t, l.now)
+	}
+	return t.Format(jsonTimeFormat)
+}
+
+// Report whether the format is a table printing any of the given columns
+func (l *listing) showsColumn(names ...string) bool {
+	if !l.opts.Format.isTable() {
+		return false
+	}
+	for _, column := range l.tableColumns() {
+		for _, name := range names {
+			if column == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Report whether the format is one of the tables
+func (f Format) isTable() bool {
+	return f == FormatCSV || f == FormatTSV
+}
@@ -0,0 +1,106 @@
+package lister
+
+import (
+	"encoding/csv"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// Names that need quoting in a CSV or TSV field
+var awkwardNames = []string{"a,b", `say "hi"`, "line\nbreak", "tab\there", " spaced ", "plain"}
+
+func TestTableRows(t *testing.T) {
+	root := makeTree(t, awkwardNames...)
+	tests := []struct {
+		name   string
+		format Format
+		comma  rune
+	}{
+		{"csv", FormatCSV, ','},
+		{"tsv", FormatTSV, '\t'},
+	}
+	for _, test := range tests {
+		opts := Options{Format: test.format, Columns: []string{"name", "size", "path"}}
+		out, errs := listOutput(t, opts, root)
+		if errs != "" {
+			t.Errorf("%s: diagnostics %q", test.name, errs)
+		}
+		reader := csv.NewReader(strings.NewReader(out))
+		reader.Comma = test.comma
+		records, err := reader.ReadAll()
+		if err != nil {
+			t.Fatalf("%s: %v in %q", test.name, err, out)
+		}
+		if len(records) != len(awkwardNames)+1 {
+			t.Fatalf("%s: got %d records, want %d: %q", test.name, len(records), len(awkwardNames)+1, records)
+		}
+		if got := strings.Join(records[0], " "); got != "name size path" {
+			t.Errorf("%s: header %q", test.name, got)
+		}
+		found := map[string]bool{}
+		for _, record := range records[1:] {
+			name := record[0]
+			found[name] = true
+			// makeTree writes every name into its file
+			if record[1] != strconv.Itoa(len(name)) {
+				t.Errorf("%s: %q has size %s, want %d", test.name, name, record[1], len(name))
+			}
+			if record[2] != filepath.Join(root, name) {
+				t.Errorf("%s: %q has path %q", test.name, name, record[2])
+			}
+		}
+		for _, name := range awkwardNames {
+			if !found[name] {
+				t.Errorf("%s: no row for %q in %q", test.name, name, out)
+			}
+		}
+	}
+}
+
+func TestTableColumnsAndHeader(t *testing.T) {
+	root := makeTree(t, "file", "dangling -> missing")
+	tests := []struct {
+		name       string
+		opts       Options
+		wantHeader string // Empty when there is no header row
+		wantRows   []string
+	}{
+		{
+			name:       "default columns",
+			opts:       Options{Format: FormatCSV},
+			wantHeader: "mode,links,user,group,size,time,path,target",
+		},
+		{
+			name:       "like -i -s -g -o",
+			opts:       Options{Format: FormatCSV, Inode: true, Size: true, OmitOwner: true, OmitGroup: true},
+			wantHeader: "inode,blocks,mode,links,size,time,path,target",
+		},
+		{
+			name:     "no header",
+			opts:     Options{Format: FormatCSV, Columns: []string{"name", "type", "target"}, OmitHeader: true},
+			wantRows: []string{"dangling,symlink,missing", "file,file,"},
+		},
+		// Only the path, name and type of a file that cannot be followed are known
+		{
+			name:       "broken",
+			opts:       Options{Format: FormatTSV, Columns: []string{"name", "type", "size", "links"}, Dereference: DereferenceAlways},
+			wantHeader: "name\ttype\tsize\tlinks",
+			wantRows:   []string{"dangling\tsymlink\t\t", "file\tfile\t4\t1"},
+		},
+	}
+	for _, test := range tests {
+		out, _ := listOutput(t, test.opts, root)
+		lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+		if test.wantHeader != "" {
+			if lines[0] != test.wantHeader {
+				t.Errorf("%s: header %q, want %q", test.name, lines[0], test.wantHeader)
+			}
+			lines = lines[1:]
+		}
+		if test.wantRows != nil && strings.Join(lines, "\n") != strings.Join(test.wantRows, "\n") {
+			t.Errorf("%s: rows %q, want %q", test.name, lines, test.wantRows)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package lister
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimeStyle selects how the long format prints timestamps. Both formats
+// use strftime directives. The zero value is the traditional ls style.
+type TimeStyle struct {
+	Recent   string // Format of timestamps less than six months old
+	Old      string // Format of older timestamps and of those in the future
+	Explicit bool   // Whether the formats were chosen, empty ones included
+}
+
+// Formats of the traditional "locale" style in the C locale
+const (
+	localeRecent = "%b %e %H:%M"
+	localeOld    = "%b %e  %Y"
+)
+
+// Time styles that can be selected by name, as in --time-style=long-iso
+var namedTimeStyles = map[string]TimeStyle{
+	"full-iso": {Recent: "%Y-%m-%d %H:%M:%S.%N %z", Old: "%Y-%m-%d %H:%M:%S.%N %z"},
+	"long-iso": {Recent: "%Y-%m-%d %H:%M", Old: "%Y-%m-%d %H:%M"},
+	"iso":      {Recent: "%m-%d %H:%M", Old: "%Y-%m-%d "},
+	"locale":   {Recent: localeRecent, Old: localeOld},
+}
+
+// TimeStyleNames lists the styles ParseTimeStyle accepts by name
+var TimeStyleNames = []string{"full-iso", "long-iso", "iso", "locale"}
+
+// ParseTimeStyle parses a --time-style argument: one of TimeStyleNames
+// or "+FORMAT", optionally prefixed with "posix-". A FORMAT containing a
+// newline uses the part before it for old timestamps and the part after
+// it for recent ones. posixLocale tells whether LC_TIME is the C or POSIX
+// locale, where like GNU ls any "posix-" style means the locale style.
+func ParseTimeStyle(spec string, posixLocale bool) (TimeStyle, error) {
+	if strings.HasPrefix(spec, "posix-") {
+		if posixLocale {
+			return ParseTimeStyle("locale", posixLocale)
+		}
+		return ParseTimeStyle(spec[len("posix-"):], posixLocale)
+	}
+	if strings.HasPrefix(spec, "+") {
+		old, recent, twoFormats := strings.Cut(spec[1:], "\n")
+		if !twoFormats {
+			recent = old
+		} else if strings.Contains(recent, "\n") {
+			return TimeStyle{}, errors.New("invalid time style format")
+		}
+		return TimeStyle{Recent: recent, Old: old, Explicit: true}, nil
+	}
+	style, ok := namedTimeStyles[spec]
+	if !ok {
+		return TimeStyle{}, errors.New("invalid time style")
+	}
+	style.Explicit = true
+	return style, nil
+}
+
+// Format t with the recent or the old format, depending on how far it is
+// from now
+func (ts TimeStyle) format(t, now time.Time) string {
+	recent, old := ts.Recent, ts.Old
+	if !ts.Explicit {
+		recent, old = localeRecent, localeOld
+	}
+	// Timestamps in the future or older than six months show the year
+	if t.After(now) || t.Before(now.AddDate(0, -6, 0)) {
+		return strftime(old, t)
+	}
+	return strftime(recent, t)
+}
+
+// Render t with a strftime format. GNU extensions are supported: %N for
+// nanoseconds (with an optional digit count as in %3N), %:z, and the '-',
+// '_', '0' and '^' flags.
+func strftime(format string, t time.Time) string {
+	var b strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' || i+1 == len(format) {
+			b.WriteByte(format[i])
+			continue
+		}
+		start := i
+		i++
+		// Flags
+		pad := byte(0)
+		upper := false
+		for ; i < len(format) && strings.IndexByte("-_0^#", format[i]) >= 0; i++ {
+			switch format[i] {
+			case '^':
+				upper = true
+			case '#':
+				// Swapping case is rarely used; treat it as a no-op
+			default:
+				pad = format[i]
+			}
+		}
+		// Field width
+		width := -1
+		for ; i < len(format) && isDigit(format[i]); i++ {
+			if width < 0 {
+				width = 0
+			}
+			width = width*10 + int(format[i]-'0')
+		}
+		// The %:z extension
+		colon := false
+		if i+1 < len(format) && format[i] == ':' && format[i+1] == 'z' {
+			colon = true
+			i++
+		}
+		if i >= len(format) {
+			b.WriteString(format[start:])
+			break
+		}
+		text, ok := strftimeDirective(format[i], t, pad, width, colon)
+		if !ok {
+			// Unknown directives are copied as they are
+			b.WriteString(format[start : i+1])
+			continue
+		}
+		if upper {
+			text = strings.ToUpper(text)
+		}
+		b.WriteString(text)
+	}
+	return b.String()
+}
+
+// Expand a single strftime directive. Numbers are padded to their usual
+// width with their usual pad character unless flags say otherwise.
+func strftimeDirective(directive byte, t time.Time, pad byte, width int, colon bool) (string, bool) {
+	// Numeric directives: value, natural width and natural padding
+	number := func(value, natural int, naturalPad byte) string {
+		if pad == 0 {
+			pad = naturalPad
+		}
+		if width >= 0 {
+			natural = width
+		}
+		digits := strconv.Itoa(value)
+		if pad == '-' || len(digits) >= natural {
+			return digits
+		}
+		fill := "0"
+		if pad == '_' {
+			fill = " "
+		}
+		return strings.Repeat(fill, natural-len(digits)) + digits
+	}
+	hour12 := t.Hour() % 12
+	if hour12 == 0 {
+		hour12 = 12
+	}
+	isoYear, isoWeek := t.ISOWeek()
+	switch directive {
+	case 'a':
+		return t.Format("Mon"), true
+	case 'A':
+		return t.Format("Monday"), true
+	case 'b', 'h':
+		return t.Format("Jan"), true
+	case 'B':
+		return t.Format("January"), true
+	case 'c':
+		return strftime("%a %b %e %H:%M:%S %Y", t), true
+	case 'C':
+		return number(t.Year()/100, 2, '0'), true
+	case 'd':
+		return number(t.Day(), 2, '0'), true
+	case 'D':
+		return strftime("%m/%d/%y", t), true
+	case 'e':
+		return number(t.Day(), 2, '_'), true
+	case 'F':
+		return strftime("%Y-%m-%d", t), true
+	case 'g':
+		return number(isoYear%100, 2, '0'), true
+	case 'G':
+		return number(isoYear, 4, '0'), true
+	case 'H':
+		return number(t.Hour(), 2, '0'), true
+	case 'I':
+		return number(hour12, 2, '0'), true
+	case 'j':
+		return number(t.YearDay(), 3, '0'), true
+	case 'k':
+		return number(t.Hour(), 2, '_'), true
+	case 'l':
+		return number(hour12, 2, '_'), true
+	case 'm':
+		return number(int(t.Month()), 2, '0'), true
+	case 'M':
+		return number(t.Minute(), 2, '0'), true
+	case 'n':
+		return "\n", true
+	case 'N':
+		// Nanoseconds, truncated to the requested number of digits
+		digits := strconv.Itoa(t.Nanosecond())
+		digits = strings.Repeat("0", 9-len(digits)) + digits
+		if width > 0 && width < 9 {
+			digits = digits[:width]
+		}
+		return digits, true
+	case 'p':
+		return t.Format("PM"), true
+	case 'P':
+		return strings.ToLower(t.Format("PM")), true
+	case 'q':
+		return number((int(t.Month())-1)/3+1, 1, '0'), true
+	case 'r':
+		return strftime("%I:%M:%S %p", t), true
+	case 'R':
+		return strftime("%H:%M", t), true
+	case 's':
+		return strconv.FormatInt(t.Unix(), 10), true
+	case 'S':
+		return number(t.Second(), 2, '0'), true
+	case 't':
+		return "\t", true
+	case 'T':
+		return strftime("%H:%M:%S", t), true
+	case 'u':
+		weekday := int(t.Weekday())
+		if weekday == 0 {
+			weekday = 7
+		}
+		return number(weekday, 1, '0'), true
+	case 'U':
+		return number((t.YearDay()+6-int(t.Weekday()))/7, 2, '0'), true
+	case 'V':
+		return number(isoWeek, 2, '0'), true
+	case 'w':
+		return number(int(t.Weekday()), 1, '0'), true
+	case 'W':
+		return number((t.YearDay()+6-(int(t.Weekday())+6)%7)/7, 2, '0'), true
+	case 'x':
+		return strftime("%m/%d/%y", t), true
+	case 'X':
+		return strftime("%H:%M:%S", t), true
+	case 'y':
+		return number(t.Year()%100, 2, '0'), true
+	case 'Y':
+		return number(t.Year(), 4, '0'), true
+	case 'z':
+		if colon {
+			return t.Format("-07:00"), true
+		}
+		return t.Format("-0700"), true
+	case 'Z':
+		return t.Format("MST"), true
+	case '%':
+		return "%", true
+	}
+	return "", false
+}
@@ -0,0 +1,120 @@
+package lister
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimeStyle(t *testing.T) {
+	tests := []struct {
+		spec        string
+		posixLocale bool
+		want        TimeStyle
+		wantErr     bool
+	}{
+		{"full-iso", false, namedTimeStyles["full-iso"], false},
+		{"long-iso", true, namedTimeStyles["long-iso"], false},
+		{"iso", false, namedTimeStyles["iso"], false},
+		{"locale", false, namedTimeStyles["locale"], false},
+		{"bogus", false, TimeStyle{}, true},
+		{"", false, TimeStyle{}, true},
+		// One format for every timestamp, or old and recent ones apart
+		{"+%Y", false, TimeStyle{Recent: "%Y", Old: "%Y"}, false},
+		{"+", false, TimeStyle{}, false},
+		{"+%Y\n%H:%M", false, TimeStyle{Recent: "%H:%M", Old: "%Y"}, false},
+		{"+%Y\n", false, TimeStyle{Old: "%Y"}, false},
+		{"+a\nb\nc", false, TimeStyle{}, true},
+		// posix- styles are the locale style in the C and POSIX locales,
+		// whatever follows
+		{"posix-iso", true, namedTimeStyles["locale"], false},
+		{"posix-+%Y", true, namedTimeStyles["locale"], false},
+		{"posix-bogus", true, namedTimeStyles["locale"], false},
+		// Elsewhere the prefix is dropped, as many times as it is given
+		{"posix-iso", false, namedTimeStyles["iso"], false},
+		{"posix-posix-long-iso", false, namedTimeStyles["long-iso"], false},
+		{"posix-+%Y", false, TimeStyle{Recent: "%Y", Old: "%Y"}, false},
+		{"posix-bogus", false, TimeStyle{}, true},
+		{"posix-", false, TimeStyle{}, true},
+	}
+	for _, test := range tests {
+		got, err := ParseTimeStyle(test.spec, test.posixLocale)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("ParseTimeStyle(%q, %v) = %+v, want an error", test.spec, test.posixLocale, got)
+			}
+			continue
+		}
+		test.want.Explicit = true
+		if err != nil || got != test.want {
+			t.Errorf("ParseTimeStyle(%q, %v) = %+v, %v, want %+v", test.spec, test.posixLocale, got, err, test.want)
+		}
+	}
+}
+
+func TestTimeStyleFormat(t *testing.T) {
+	now := time.Date(2026, 10, 16, 22, 46, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		style TimeStyle
+		t     time.Time
+		want  string
+	}{
+		{"zero value recent", TimeStyle{}, now.AddDate(0, -1, 0), "Sep 16 22:46"},
+		{"zero value old", TimeStyle{}, now.AddDate(-1, 0, 0), "Oct 16  2025"},
+		{"zero value future", TimeStyle{}, now.Add(time.Hour), "Oct 16  2026"},
+		{"iso recent", namedTimeStyles["iso"], now, "10-16 22:46"},
+		{"iso old", namedTimeStyles["iso"], now.AddDate(0, -7, 0), "2026-03-16 "},
+		{"+FORMAT pair", TimeStyle{Recent: "%H:%M", Old: "%Y"}, now.AddDate(0, -7, 0), "2026"},
+	}
+	for _, test := range tests {
+		test.style.Explicit = test.style != TimeStyle{}
+		if got := test.style.format(test.t, now); got != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestStrftime(t *testing.T) {
+	moment := time.Date(2026, 3, 5, 7, 4, 9, 123456789, time.FixedZone("CET", 3600))
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"%Y-%m-%d %H:%M:%S", "2026-03-05 07:04:09"},
+		{"%b %e %a", "Mar  5 Thu"},
+		{"%B %A", "March Thursday"},
+		{"%F %T", "2026-03-05 07:04:09"},
+		{"%D %R", "03/05/26 07:04"},
+		{"%I %l %p %P", "07  7 AM am"},
+		{"%j %u %w", "064 4 4"},
+		{"%U %W %V %G %g", "09 09 10 2026 26"},
+		{"%C %y %q", "20 26 1"},
+		{"%s", "1772690649"},
+		// Time zones
+		{"%z %:z %Z", "+0100 +01:00 CET"},
+		// Nanoseconds, all of them or truncated
+		{"%N", "123456789"},
+		{"%3N", "123"},
+		{"%6N", "123456"},
+		// Flags and widths
+		{"%-d %-m %-H", "5 3 7"},
+		{"%_d %_H", " 5  7"},
+		{"%0e %0k", "05 07"},
+		{"%^b %^A", "MAR THURSDAY"},
+		{"%#b", "Mar"},
+		{"%5d %_5m %-5Y", "00005     3 2026"},
+		// Literal text and what cannot be expanded
+		{"%%", "%"},
+		{"a%nb%tc", "a\nb\tc"},
+		{"%Q", "%Q"},
+		{"%-Q", "%-Q"},
+		{"100%", "100%"},
+		{"%_", "%_"},
+		{"", ""},
+	}
+	for _, test := range tests {
+		if got := strftime(test.format, moment); got != test.want {
+			t.Errorf("strftime(%q) = %q, want %q", test.format, got, test.want)
+		}
+	}
+}
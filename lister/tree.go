@@ -0,0 +1,168 @@
+package lister
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// TreeStyle selects whether directories are drawn as trees, and with which
+// characters
+type TreeStyle int
+
+const (
+	TreeNone    TreeStyle = iota // Directories are listed block by block, the default
+	TreeUnicode                  // Box-drawing connectors (--tree)
+	TreeASCII                    // ASCII connectors, for terminals without UTF-8
+)
+
+// Connectors drawn in front of the entries of a tree
+type treeConnectors struct {
+	middle  string // In front of an entry followed by others
+	last    string // In front of the last entry of a directory
+	through string // Below an entry followed by others, down to the next one
+	blank   string // Below the last entry of a directory
+}
+
+// Connectors of every tree style
+var treeCharsets = map[TreeStyle]treeConnectors{
+	TreeUnicode: {middle: "├── ", last: "└── ", through: "│   ", blank: "    "},
+	TreeASCII:   {middle: "|-- ", last: "`-- ", through: "|   ", blank: "    "},
+}
+
+// An entry of a tree and the connectors drawn in front of it
+type treeRow struct {
+	entry      *Entry
+	connectors string
+}
+
+// Entries counted for the summary below the trees
+type treeCounts struct {
+	dirs, files int
+}
+
+// Count entry as a directory or a file
+func (c *treeCounts) add(entry *Entry) {
+	if entry.IsDir() {
+		c.dirs++
+	} else {
+		c.files++
+	}
+}
+
+// Report whether the listing draws trees. The JSON and table formats
+// carry paths instead and ignore Options.Tree.
+func (l *listing) drawsTree() bool {
+	return l.opts.Tree != TreeNone && !l.opts.Format.isJSON() && !l.opts.Format.isTable()
+}
+
+// Draw a tree for every operand, files first, then print how many
+// directories and files they hold. Operands listed as files count too,
+// as do directories listed themselves with -d.
+func (l *listing) listTrees(files, dirs []*Entry) error {
+	// Trees go all the way down unless MaxDepth stops them
+	l.opts.Recursive = true
+	counts := &treeCounts{}
+	for _, entry := range files {
+		counts.add(entry)
+	}
+	for _, root := range append(files, dirs...) {
+		l.operandDev = root.Dev
+		rows := []treeRow{{entry: root}}
+		if root.IsDir() && !l.opts.Directory {
+			var err error
+			if rows, err = l.treeContents(rows, root, "", 0, counts); err != nil {
+				return err
+			}
+		}
+		if err := l.printTree(rows); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(l.w, "\n%s, %s\n", plural(counts.dirs, "directory", "directories"), plural(counts.files, "file", "files"))
+	return err
+}
+
+// Add the rows of the contents of the directory dir to rows. indent
+// continues the levels above, and depth counts the levels below the
+// operand.
+func (l *listing) treeContents(rows []treeRow, dir *Entry, indent string, depth int, counts *treeCounts) ([]treeRow, error) {
+	if err := l.ctx.Err(); err != nil {
+		return rows, err
+	}
+	if !l.enterDir(dir) {
+		return rows, nil
+	}
+	defer l.leaveDir(dir)
+	names, err := l.readDir(dir.Path)
+	if err != nil {
+		l.fail(depth == 0, "cannot open directory %s: %s", quoteName(dir.Path), describe(err))
+		return rows, nil
+	}
+	// The tree itself shows where . and .. lead
+	children := []string{}
+	for _, name := range names {
+		if name != "." && name != ".." {
+			children = append(children, name)
+		}
+	}
+	entries := l.dirEntries(dir.Path, children)
+	l.sortEntries(entries)
+	descend := map[*Entry]bool{}
+	for _, entry := range l.subdirs(entries, depth) {
+		descend[entry] = true
+	}
+
+	charset := treeCharsets[l.opts.Tree]
+	for i, entry := range entries {
+		connector, below := charset.middle, charset.through
+		if i == len(entries)-1 {
+			connector, below = charset.last, charset.blank
+		}
+		rows = append(rows, treeRow{entry: entry, connectors: indent + connector})
+		counts.add(entry)
+		if descend[entry] {
+			if rows, err = l.treeContents(rows, entry, indent+below, depth+1, counts); err != nil {
+				return rows, err
+			}
+		}
+	}
+	return rows, nil
+}
+
+// Print the rows of a tree, each entry behind the connectors leading to
+// it. Columns in front of the names keep them out of the way, so the
+// connectors go right before the names, and are aligned over the whole
+// tree.
+func (l *listing) printTree(rows []treeRow) error {
+	entries := make([]*Entry, 0, len(rows))
+	for _, row := range rows {
+		entries = append(entries, row.entry)
+	}
+	l.padNames = l.someQuoted(entries)
+	if l.opts.Format == FormatLong {
+		l.minorWidth = minorWidthOf(entries)
+		for _, row := range rows {
+			text, nameStart, _ := l.longRow(row.entry)
+			nameColumn := strings.LastIndex(text[:nameStart], "\t") + 1
+			l.fp.AddRow(text[:nameColumn] + row.connectors + text[nameColumn:])
+		}
+		return l.fp.Flush(l.w)
+	}
+	prefixes := l.infoPrefixes(entries)
+	for i, row := range rows {
+		line := prefixes[i] + row.connectors + l.displayName(row.entry) + l.opts.Indicator.indicator(row.entry.Mode) + "\n"
+		if _, err := io.WriteString(l.w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Format a count with the singular or plural noun it needs
+func plural(n int, singular, pluralForm string) string {
+	if n == 1 {
+		return "1 " + singular
+	}
+	return fmt.Sprintf("%d %s", n, pluralForm)
+}
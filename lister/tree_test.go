@@ -0,0 +1,178 @@
+package lister
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTree(t *testing.T) {
+	root := makeTree(t, "d/a/x/deep", "d/a/y", "d/b", "d/.hidden/z", "d/c/", "file")
+	dir := filepath.Join(root, "d")
+	tests := []struct {
+		name  string
+		opts  Options
+		paths []string
+		want  []string // Lines, with the operands' own paths left blank
+	}{
+		{
+			name:  "ascii",
+			opts:  Options{Tree: TreeASCII},
+			paths: []string{dir},
+			want: []string{
+				"",
+				"|-- a",
+				"|   |-- x",
+				"|   |   `-- deep",
+				"|   `-- y",
+				"|-- b",
+				"`-- c",
+				"",
+				"3 directories, 3 files",
+			},
+		},
+		{
+			name:  "unicode",
+			opts:  Options{Tree: TreeUnicode},
+			paths: []string{dir},
+			want: []string{
+				"",
+				"├── a",
+				"│   ├── x",
+				"│   │   └── deep",
+				"│   └── y",
+				"├── b",
+				"└── c",
+				"",
+				"3 directories, 3 files",
+			},
+		},
+		// Hidden files are drawn, . and .. are not
+		{
+			name:  "all",
+			opts:  Options{Tree: TreeASCII, All: true},
+			paths: []string{dir},
+			want: []string{
+				"",
+				"|-- .hidden",
+				"|   `-- z",
+				"|-- a",
+				"|   |-- x",
+				"|   |   `-- deep",
+				"|   `-- y",
+				"|-- b",
+				"`-- c",
+				"",
+				"4 directories, 4 files",
+			},
+		},
+		// Directories below the depth are counted but not entered
+		{
+			name:  "max depth",
+			opts:  Options{Tree: TreeASCII, MaxDepth: 1},
+			paths: []string{dir},
+			want: []string{
+				"",
+				"|-- a",
+				"|-- b",
+				"`-- c",
+				"",
+				"2 directories, 1 file",
+			},
+		},
+		{
+			name:  "max depth 2",
+			opts:  Options{Tree: TreeASCII, MaxDepth: 2, Indicator: IndicatorSlash},
+			paths: []string{dir},
+			want: []string{
+				"",
+				"|-- a/",
+				"|   |-- x/",
+				"|   `-- y",
+				"|-- b",
+				"`-- c/",
+				"",
+				"3 directories, 2 files",
+			},
+		},
+		// File operands come first and count as files
+		{
+			name:  "files and directories",
+			opts:  Options{Tree: TreeASCII, MaxDepth: 1, Reverse: true},
+			paths: []string{dir, filepath.Join(root, "file")},
+			want: []string{
+				"",
+				"",
+				"|-- c",
+				"|-- b",
+				"`-- a",
+				"",
+				"2 directories, 2 files",
+			},
+		},
+		// So do directories listed themselves
+		{
+			name:  "directory itself",
+			opts:  Options{Tree: TreeASCII, Directory: true},
+			paths: []string{dir},
+			want:  []string{"", "", "1 directory, 0 files"},
+		},
+	}
+	for _, test := range tests {
+		out, errs := listOutput(t, test.opts, test.paths...)
+		if errs != "" {
+			t.Errorf("%s: diagnostics %q", test.name, errs)
+		}
+		lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+		for i, line := range lines {
+			for _, path := range test.paths {
+				if strings.TrimSuffix(line, "/") == path {
+					lines[i] = ""
+				}
+			}
+		}
+		if got := strings.Join(lines, "\n"); got != strings.Join(test.want, "\n") {
+			t.Errorf("%s: got\n%s\nwant\n%s", test.name, got, strings.Join(test.want, "\n"))
+		}
+	}
+}
+
+// Columns in front of the names stay aligned over the whole tree, with
+// the connectors between them and the names
+func TestTreeColumns(t *testing.T) {
+	root := makeTree(t, "d/a b", "d/sub/plain")
+	dir := filepath.Join(root, "d")
+	tests := []struct {
+		name string
+		opts Options
+		want []string // Text after the columns
+	}{
+		{"inodes", Options{Tree: TreeASCII, Inode: true}, []string{dir, "|-- a b", "`-- sub", "    `-- plain"}},
+		{"sizes", Options{Tree: TreeASCII, Size: true}, []string{dir, "|-- a b", "`-- sub", "    `-- plain"}},
+		// A quoted name anywhere in the tree moves the others over
+		{"long", Options{Tree: TreeASCII, Format: FormatLong, Quoting: QuotingShellEscape}, []string{" " + dir, "|-- 'a b'", "`--  sub", "    `--  plain"}},
+	}
+	for _, test := range tests {
+		out, _ := listOutput(t, test.opts, dir)
+		lines := strings.Split(out, "\n")
+		if len(lines) < len(test.want) {
+			t.Fatalf("%s: got %q", test.name, out)
+		}
+		column := -1
+		for i, want := range test.want {
+			start := strings.Index(lines[i], want)
+			if start < 0 || start+len(want) != len(lines[i]) {
+				t.Errorf("%s: line %q does not end with %q", test.name, lines[i], want)
+				continue
+			}
+			if strings.TrimSpace(lines[i][:start]) == "" {
+				t.Errorf("%s: no columns in %q", test.name, lines[i])
+			}
+			if column < 0 {
+				column = start
+			} else if start != column {
+				t.Errorf("%s: %q starts at %d, want %d in\n%s", test.name, want, start, column, out)
+			}
+		}
+	}
+}
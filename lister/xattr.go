@@ -0,0 +1,119 @@
+package lister
+
+import (
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+// Extended attribute names that decide which marker follows the mode column
+const (
+	xattrACLAccess  = "system.posix_acl_access"
+	xattrACLDefault = "system.posix_acl_default"
+	xattrSELinux    = "security.selinux"
+	xattrUserPrefix = "user."
+)
+
+// Return the attribute marker GNU ls prints after the mode string:
+// "+" when the file carries a POSIX ACL, "@" when it has user.* extended
+// attributes, "." when it only has an SELinux context and "" otherwise.
+// Symbolic links are inspected themselves, not their targets.
+func xattrMarker(path string) string {
+	names := listXattr(path)
+	hasACL, hasUser, hasSELinux := false, false, false
+	for _, name := range names {
+		switch {
+		case name == xattrACLAccess || name == xattrACLDefault:
+			hasACL = true
+		case name == xattrSELinux:
+			hasSELinux = true
+		case strings.HasPrefix(name, xattrUserPrefix):
+			hasUser = true
+		}
+	}
+	// An access ACL only counts when it holds more than the three base entries
+	if hasACL && !aclIsMeaningful(path) {
+		hasACL = false
+	}
+	switch {
+	case hasACL:
+		return "+"
+	case hasUser:
+		return "@"
+	case hasSELinux:
+		return "."
+	}
+	return ""
+}
+
+// List the extended attribute names of path without following symlinks.
+// Filesystems without xattr support simply yield no names.
+func listXattr(path string) []string {
+	// Ask the kernel for the size first, then read the names into a buffer of that size
+	size, err := lxattrCall(syscall.SYS_LLISTXATTR, path, "", nil)
+	if err != nil || size <= 0 {
+		return nil
+	}
+	buf := make([]byte, size)
+	size, err = lxattrCall(syscall.SYS_LLISTXATTR, path, "", buf)
+	if err != nil || size <= 0 {
+		return nil
+	}
+	// The names come back as a sequence of NUL terminated strings
+	names := []string{}
+	for _, name := range strings.Split(string(buf[:size]), "\x00") {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Report whether the POSIX ACLs on path hold anything beyond what the mode
+// bits already express. A default ACL on a directory always counts.
+func aclIsMeaningful(path string) bool {
+	if size, err := lxattrCall(syscall.SYS_LGETXATTR, path, xattrACLDefault, nil); err == nil && size > 0 {
+		return true
+	}
+	buf := make([]byte, 256)
+	size, err := lxattrCall(syscall.SYS_LGETXATTR, path, xattrACLAccess, buf)
+	if err == syscall.ERANGE {
+		// Too many entries to fit the buffer can only mean an extended ACL
+		return true
+	}
+	if err != nil {
+		return false
+	}
+	// The xattr is a 4 byte header followed by 8 byte entries; a minimal ACL
+	// holds exactly the user, group and other entries
+	entries := (size - 4) / 8
+	return entries > 3
+}
+
+// Call llistxattr (name empty) or lgetxattr on path, filling buf when it is
+// not nil. It returns the size reported by the kernel.
+func lxattrCall(trap uintptr, path, name string, buf []byte) (int, error) {
+	pathPtr, err := syscall.BytePtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+	var bufPtr unsafe.Pointer
+	if len(buf) > 0 {
+		bufPtr = unsafe.Pointer(&buf[0])
+	}
+	var r0 uintptr
+	var errno syscall.Errno
+	if trap == syscall.SYS_LLISTXATTR {
+		r0, _, errno = syscall.Syscall(trap, uintptr(unsafe.Pointer(pathPtr)), uintptr(bufPtr), uintptr(len(buf)))
+	} else {
+		namePtr, err := syscall.BytePtrFromString(name)
+		if err != nil {
+			return 0, err
+		}
+		r0, _, errno = syscall.Syscall6(trap, uintptr(unsafe.Pointer(pathPtr)), uintptr(unsafe.Pointer(namePtr)), uintptr(bufPtr), uintptr(len(buf)), 0, 0)
+	}
+	if errno != 0 {
+		return 0, errno
+	}
+	return int(r0), nil
+}
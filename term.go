@@ -0,0 +1,38 @@
+package main
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// The environment of the process, with stdout as its terminal
+func systemEnvironment() environment {
+	return environment{
+		getenv:   os.Getenv,
+		terminal: isTerminal(os.Stdout.Fd()),
+		width:    terminalWidth(os.Stdout.Fd()),
+		stderr:   os.Stderr,
+	}
+}
+
+// Report whether fd refers to a terminal
+func isTerminal(fd uintptr) bool {
+	var termios syscall.Termios
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TCGETS, uintptr(unsafe.Pointer(&termios)))
+	return errno == 0
+}
+
+// Return the number of columns of the terminal at fd, or 0 when fd is not
+// a terminal or does not know its size
+func terminalWidth(fd uintptr) int {
+	// struct winsize from <sys/ioctl.h>
+	var ws struct {
+		Row, Col, Xpixel, Ypixel uint16
+	}
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TIOCGWINSZ, uintptr(unsafe.Pointer(&ws)))
+	if errno != 0 {
+		return 0
+	}
+	return int(ws.Col)
+}